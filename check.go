@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/richardjkendall/tf-auto-document/diff"
+	"github.com/richardjkendall/tf-auto-document/writer"
+)
+
+// states a generated file can be in compared to the one on disk
+const (
+	fileCreated   = "created"
+	fileChanged   = "changed"
+	fileUnchanged = "unchanged"
+)
+
+// fileChange describes what writing a generated file would do to the one on disk
+// name is relative to the repository folder
+type fileChange struct {
+	name  string
+	state string
+	diff  string
+}
+
+// compareReadmes works out what writing each of the generated files would change on disk
+func compareReadmes(repo string, files []*writer.Writer) ([]fileChange, error) {
+	var r []fileChange
+	for _, f := range files {
+		want, err := f.Render()
+		if err != nil {
+			return r, err
+		}
+		name, err := filepath.Rel(repo, f.FileName())
+		if err != nil {
+			return r, err
+		}
+		change := fileChange{name: filepath.ToSlash(name), state: fileChanged}
+		fromName := "a/" + change.name
+		existing, err := ioutil.ReadFile(f.FileName())
+		if os.IsNotExist(err) {
+			fromName = "/dev/null"
+			change.state = fileCreated
+		} else if err != nil {
+			return r, err
+		}
+		change.diff = diff.Unified(string(existing), want, fromName, "b/"+change.name)
+		if change.diff == "" {
+			change.state = fileUnchanged
+		}
+		r = append(r, change)
+	}
+	return r, nil
+}
+
+// checkReadmes compares each generated file with the one on disk and prints a unified diff for any which are stale
+// returns the number of stale files
+func checkReadmes(w io.Writer, repo string, files []*writer.Writer) (int, error) {
+	stale := 0
+	changes, err := compareReadmes(repo, files)
+	if err != nil {
+		return stale, err
+	}
+	for _, c := range changes {
+		if c.state != fileUnchanged {
+			stale++
+			fmt.Fprint(w, c.diff)
+		}
+	}
+	return stale, nil
+}
+
+// dryRun reports what writing the generated files would do, with a diff of the changes, without writing anything
+func dryRun(w io.Writer, repo string, files []*writer.Writer, colour bool) error {
+	changes, err := compareReadmes(repo, files)
+	if err != nil {
+		return err
+	}
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.state]++
+		fmt.Fprintf(w, "%s would be %s\n", c.name, c.state)
+		if colour {
+			fmt.Fprint(w, diff.Colour(c.diff))
+		} else {
+			fmt.Fprint(w, c.diff)
+		}
+	}
+	fmt.Fprintf(w, "%d files would be created, %d changed and %d unchanged\n", counts[fileCreated], counts[fileChanged], counts[fileUnchanged])
+	return nil
+}
+
+// useColour checks if output is going to a terminal and colour has not been turned off with NO_COLOR
+func useColour() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
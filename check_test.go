@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/writer"
+)
+
+// checkTestFiles writes a repository with a README which is up to date, one which is out of date, one which only differs by its last newline and one which is missing
+// returns the folder of the repository and the generated files
+func checkTestFiles(t *testing.T) (string, []*writer.Writer) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	var files []*writer.Writer
+	for _, name := range []string{"unchanged", "changed", "newline", "created"} {
+		f := writer.New(filepath.Join(repo, name, "README.md"))
+		f.H1(name)
+		files = append(files, f)
+	}
+	if err := files[0].WriteFile(); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if err := writeOutputFile(files[1].FileName(), []byte("out of date\n")); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	text, err := files[2].Render()
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if err := writeOutputFile(files[2].FileName(), []byte(strings.TrimSuffix(text, "\n"))); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	return repo, files
+}
+
+func TestCompareReadmes(t *testing.T) {
+	repo, files := checkTestFiles(t)
+	defer os.RemoveAll(repo)
+	changes, err := compareReadmes(repo, files)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := []struct {
+		name  string
+		state string
+		diff  string
+	}{
+		{"unchanged/README.md", fileUnchanged, ""},
+		{"changed/README.md", fileChanged, "--- a/changed/README.md\n+++ b/changed/README.md\n"},
+		{"newline/README.md", fileChanged, "\\ No newline at end of file\n"},
+		{"created/README.md", fileCreated, "--- /dev/null\n+++ b/created/README.md\n"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, expected %d", len(changes), len(want))
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.name != w.name || c.state != w.state {
+			t.Errorf("got %s %s, expected %s %s", c.name, c.state, w.name, w.state)
+		}
+		if !strings.Contains(c.diff, w.diff) || (w.diff == "") != (c.diff == "") {
+			t.Errorf("expected the diff of %s to contain %q\n%s", w.name, w.diff, c.diff)
+		}
+	}
+}
+
+func TestCheckReadmes(t *testing.T) {
+	repo, files := checkTestFiles(t)
+	defer os.RemoveAll(repo)
+	var out bytes.Buffer
+	stale, err := checkReadmes(&out, repo, files)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	// the check command exits with an error when any files are stale
+	if stale != 3 {
+		t.Errorf("got %d stale files, expected 3", stale)
+	}
+	if strings.Contains(out.String(), "unchanged/README.md") {
+		t.Errorf("files which are up to date should not be shown\n%s", out.String())
+	}
+
+	stale, err = checkReadmes(&out, repo, files[:1])
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if stale != 0 {
+		t.Errorf("got %d stale files, expected none", stale)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	repo, files := checkTestFiles(t)
+	defer os.RemoveAll(repo)
+	var out bytes.Buffer
+	if err := dryRun(&out, repo, files, false); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, text := range []string{
+		"unchanged/README.md would be unchanged\n",
+		"changed/README.md would be changed\n--- a/changed/README.md\n",
+		"created/README.md would be created\n--- /dev/null\n",
+		"1 files would be created, 2 changed and 1 unchanged\n",
+	} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("expected %q in\n%s", text, out.String())
+		}
+	}
+
+	// nothing is written
+	if _, err := os.Stat(files[3].FileName()); !os.IsNotExist(err) {
+		t.Errorf("dry run should not create %s", files[3].FileName())
+	}
+	if data, _ := ioutil.ReadFile(files[1].FileName()); string(data) != "out of date\n" {
+		t.Errorf("dry run should not change %s", files[1].FileName())
+	}
+}
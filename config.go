@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configFileName is the name of the config file looked for in the root of the repository
+const configFileName = ".tf-auto-document.yaml"
+
+// fileConfig is the layout of the config file, each setting is the same as the flag of the same name
+// flags given on the command line override the settings in the file
+type fileConfig struct {
+	Modules    []string `yaml:"mods"`
+	Include    []string `yaml:"include"`
+	Exclude    []string `yaml:"exclude"`
+	Configs    []string `yaml:"configs"`
+	Format     string   `yaml:"format"`
+	Out        string   `yaml:"out"`
+	WebURL     string   `yaml:"weburl"`
+	Maintainer *bool    `yaml:"maintainer"`
+	Strict     *bool    `yaml:"strict"`
+	TagPattern string   `yaml:"tagpattern"`
+	Sections   []string `yaml:"sections"`
+	Templates  struct {
+		Module string `yaml:"module"`
+		Root   string `yaml:"root"`
+	} `yaml:"templates"`
+	Lint struct {
+		Rules  map[string]string `yaml:"rules"`
+		Format string            `yaml:"format"`
+	} `yaml:"lint"`
+	Root struct {
+		Title string   `yaml:"title"`
+		Intro []string `yaml:"intro"`
+	} `yaml:"root"`
+}
+
+// loadConfig reads the config file, which is the file given or the one in the root of the repository
+// it is not an error for there to be no config file in the repository, an empty config is returned
+func loadConfig(file string, repo string) (fileConfig, string, error) {
+	var c fileConfig
+	if file == "" {
+		file = filepath.Join(repo, configFileName)
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return c, "", nil
+		}
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return c, "", err
+	}
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return c, "", fmt.Errorf("could not read config file %s: %v", file, err)
+	}
+	return c, file, nil
+}
+
+// flagValues gives the settings in the config as the values of the flags they stand for
+// paths are relative to the folder of the config file, settings which are not given are left out
+func (c fileConfig) flagValues(folder string) map[string]string {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(folder, p)
+	}
+	values := map[string]string{
+		"mods":           strings.Join(c.Modules, ","),
+		"include":        strings.Join(c.Include, ","),
+		"exclude":        strings.Join(c.Exclude, ","),
+		"configs":        strings.Join(c.Configs, ","),
+		"format":         c.Format,
+		"out":            resolve(c.Out),
+		"weburl":         c.WebURL,
+		"tagpattern":     c.TagPattern,
+		"sections":       strings.Join(c.Sections, ","),
+		"moduletemplate": resolve(c.Templates.Module),
+		"roottemplate":   resolve(c.Templates.Root),
+		"lintformat":     c.Lint.Format,
+	}
+	if c.Maintainer != nil {
+		values["maintainer"] = strconv.FormatBool(*c.Maintainer)
+	}
+	if c.Strict != nil {
+		values["strict"] = strconv.FormatBool(*c.Strict)
+	}
+	var rules []string
+	for rule, severity := range c.Lint.Rules {
+		rules = append(rules, rule+"="+severity)
+	}
+	sort.Strings(rules)
+	values["lintrules"] = strings.Join(rules, ",")
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// applyConfig sets the flags from the config file, except those given on the command line
+func applyConfig(flags *flag.FlagSet, c fileConfig, folder string) error {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for name, value := range c.flagValues(folder) {
+		if given[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("could not use %s from the config file: %v", name, err)
+		}
+	}
+	return nil
+}
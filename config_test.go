@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file to a new folder, returning the folder
+func writeConfig(t *testing.T, text string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, configFileName), []byte(text), 0644); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	return dir
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := writeConfig(t, "mods:\n  - modules\nexclude:\n  - \"**/examples\"\nstrict: false\nlint:\n  rules:\n    variable-type: error\n")
+	defer os.RemoveAll(dir)
+	c, path, err := loadConfig("", dir)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if path != filepath.Join(dir, configFileName) {
+		t.Errorf("got path %q", path)
+	}
+	if !reflect.DeepEqual(c.Modules, []string{"modules"}) || !reflect.DeepEqual(c.Exclude, []string{"**/examples"}) || c.Strict == nil || *c.Strict || c.Maintainer != nil || c.Lint.Rules["variable-type"] != "error" {
+		t.Errorf("got %+v", c)
+	}
+
+	// it is not an error for a repository to have no config file, but it is for a config file which is given
+	empty, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(empty)
+	if c, path, err := loadConfig("", empty); err != nil || path != "" || !reflect.DeepEqual(c, fileConfig{}) {
+		t.Errorf("got %+v, %q and %v with no config file", c, path, err)
+	}
+	if _, _, err := loadConfig(filepath.Join(empty, "missing.yaml"), empty); err == nil {
+		t.Errorf("a config file which does not exist should fail")
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	for _, text := range []string{"modules: modules\n", "lint:\n  rule:\n    variable-type: error\n", "templates:\n  readme: x.tmpl\n"} {
+		dir := writeConfig(t, text)
+		_, _, err := loadConfig("", dir)
+		os.RemoveAll(dir)
+		if err == nil || !strings.Contains(err.Error(), "not found in type") {
+			t.Errorf("%q: got %v, want an error for the unknown key", text, err)
+		}
+	}
+}
+
+func TestFlagValues(t *testing.T) {
+	yes := true
+	c := fileConfig{
+		Modules:    []string{"modules", "platform"},
+		Include:    []string{"modules/aws/**", "platform/**"},
+		Out:        "docs",
+		Maintainer: &yes,
+		Sections:   []string{"variables", "outputs"},
+	}
+	c.Templates.Module = "templates/module.tmpl"
+	c.Templates.Root = "/shared/root.tmpl"
+	c.Lint.Rules = map[string]string{"variable-type": "error", "any-type": "off"}
+	want := map[string]string{
+		"mods":           "modules,platform",
+		"include":        "modules/aws/**,platform/**",
+		"out":            filepath.Join("config", "docs"),
+		"maintainer":     "true",
+		"sections":       "variables,outputs",
+		"moduletemplate": filepath.Join("config", "templates", "module.tmpl"),
+		"roottemplate":   "/shared/root.tmpl",
+		"lintrules":      "any-type=off,variable-type=error",
+	}
+	if got := c.flagValues("config"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir := writeConfig(t, "mods:\n  - platform\nout: docs\nformat: html\nmaintainer: true\n")
+	defer os.RemoveAll(dir)
+	c, path, err := loadConfig("", dir)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	mods := flags.String("mods", "modules", "")
+	out := flags.String("out", "", "")
+	format := flags.String("format", "markdown", "")
+	maintainer := flags.Bool("maintainer", false, "")
+	if err := flags.Parse([]string{"-mods", "modules,other"}); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if err := applyConfig(flags, c, filepath.Dir(path)); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	// the flag given on the command line wins, the others come from the config file
+	if *mods != "modules,other" {
+		t.Errorf("mods given on the command line was changed to %q", *mods)
+	}
+	if *out != filepath.Join(dir, "docs") || *format != "html" || !*maintainer {
+		t.Errorf("got out %q, format %q and maintainer %v from the config file", *out, *format, *maintainer)
+	}
+
+	// a setting for a flag which does not take that kind of value is reported
+	bad := flag.NewFlagSet("test", flag.ContinueOnError)
+	bad.Int("format", 0, "")
+	if err := applyConfig(bad, fileConfig{Format: "html"}, dir); err == nil || !strings.Contains(err.Error(), "could not use format") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// number of unchanged lines shown around each change
+const contextLines = 3
+
+// noNewline marks a last line without a newline, so that it differs from the same line with one
+// it is written as the line git uses for it
+const noNewline = "\n\\ No newline at end of file"
+
+// op is a single line of an edit script
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified compares two texts line by line and returns the differences in unified diff format
+// returns an empty string if the texts are the same
+func Unified(from string, to string, fromName string, toName string) string {
+	if from == to {
+		return ""
+	}
+	ops := editScript(diffLines(from), diffLines(to))
+	var b strings.Builder
+	b.WriteString("--- " + fromName + "\n")
+	b.WriteString("+++ " + toName + "\n")
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// extend the hunk until there is a long enough run of unchanged lines
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*contextLines {
+				break
+			}
+		}
+		hunkStart := start - contextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := end + contextLines
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+		writeHunk(&b, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+	return b.String()
+}
+
+// writeHunk writes the header and lines of a hunk covering ops[start:end]
+func writeHunk(b *strings.Builder, ops []op, start int, end int) {
+	fromLine, toLine := 1, 1
+	for _, o := range ops[:start] {
+		if o.kind != '+' {
+			fromLine++
+		}
+		if o.kind != '-' {
+			toLine++
+		}
+	}
+	fromCount, toCount := 0, 0
+	for _, o := range ops[start:end] {
+		if o.kind != '+' {
+			fromCount++
+		}
+		if o.kind != '-' {
+			toCount++
+		}
+	}
+	// an empty range is numbered from the line before it
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+	for _, o := range ops[start:end] {
+		b.WriteString(string(o.kind) + o.line + "\n")
+	}
+}
+
+// editScript works out the lines to remove and add to turn a into b, using the longest common subsequence
+func editScript(a []string, b []string) []op {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits text into lines, a trailing newline does not start another line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines splits text into lines to compare, marking the last line if it has no newline
+func diffLines(text string) []string {
+	lines := splitLines(text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// ANSI escape codes used to colour diffs
+const (
+	colourReset = "\x1b[0m"
+	colourBold  = "\x1b[1m"
+	colourRed   = "\x1b[31m"
+	colourGreen = "\x1b[32m"
+	colourCyan  = "\x1b[36m"
+)
+
+// Colour adds terminal colours to the unified diff of a file, removed lines in red, added lines in green and hunk headers in cyan
+func Colour(unified string) string {
+	var b strings.Builder
+	for i, line := range splitLines(unified) {
+		switch {
+		case i < 2:
+			b.WriteString(colourBold + line + colourReset + "\n")
+		case strings.HasPrefix(line, "@@"):
+			b.WriteString(colourCyan + line + colourReset + "\n")
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(colourRed + line + colourReset + "\n")
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(colourGreen + line + colourReset + "\n")
+		default:
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnifiedSame(t *testing.T) {
+	if got := Unified("a\nb\n", "a\nb\n", "a", "b"); got != "" {
+		t.Errorf("expected no diff, got %q", got)
+	}
+}
+
+func TestUnifiedChange(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	to := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	want := `--- a/README.md
++++ b/README.md
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`
+	if got := Unified(from, to, "a/README.md", "b/README.md"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedNewFile(t *testing.T) {
+	want := "--- /dev/null\n+++ b/README.md\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := Unified("", "a\nb\n", "/dev/null", "b/README.md"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnifiedNoNewline(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"a\nb", "a\nb\n", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"a\nb\n", "a\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"a\nb", "a\nc", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+	}
+	for _, c := range cases {
+		if got := Unified(c.from, c.to, "a", "b"); got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestColour(t *testing.T) {
+	want := "\x1b[1m--- a/README.md\x1b[0m\n\x1b[1m+++ b/README.md\x1b[0m\n\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n a\n\x1b[31m--- b\x1b[0m\n\x1b[32m+c\x1b[0m\n"
+	if got := Colour(Unified("a\n-- b\n", "a\nc\n", "a/README.md", "b/README.md")); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// matchGlob checks if a slash separated path matches a glob, where ** matches any number of folders
+// other parts of the glob are matched against a single folder as in path.Match
+func matchGlob(pattern string, name string) bool {
+	return matchParts(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(name, "/"))
+}
+
+// matchParts matches the folders of a path against the parts of a glob
+func matchParts(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchesAny checks if a folder matches any of a list of globs
+func matchesAny(patterns []string, folder string) bool {
+	for _, p := range patterns {
+		if matchGlob(strings.TrimSpace(p), folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTerraformFiles checks if a folder contains any .tf files
+func hasTerraformFiles(dir string) (bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".tf") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findModuleFolders finds the folders under each of the roots which contain .tf files, at any depth
+// folders are returned relative to the repository, in order and without repeats, hidden folders are skipped
+// an excluded folder is skipped along with everything in it, when there are include globs a folder has to match one
+// the folders the tool writes to are skipped too, as the site and docs hold copies of the modules
+func findModuleFolders(repo string, roots []string, opts scanOptions) ([]string, error) {
+	found := make(map[string]bool)
+	for _, root := range roots {
+		start := filepath.Join(repo, strings.TrimSpace(root))
+		err := filepath.Walk(start, func(dir string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if dir != start && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(repo, dir)
+			if err != nil {
+				return err
+			}
+			folder := filepath.ToSlash(rel)
+			if containsName(opts.skip, folder) || matchesAny(opts.exclude, folder) {
+				return filepath.SkipDir
+			}
+			// the examples of a module are documented as part of the module
+			if info.Name() == "examples" {
+				if ok, err := hasTerraformFiles(filepath.Dir(dir)); ok || err != nil {
+					return filepath.SkipDir
+				}
+			}
+			if len(opts.include) > 0 && !matchesAny(opts.include, folder) {
+				return nil
+			}
+			ok, err := hasTerraformFiles(dir)
+			// the root of the repository has the index README, so it cannot be a module
+			if ok && folder != "." {
+				found[folder] = true
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var folders []string
+	for folder := range found {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	return folders, nil
+}
+
+// skipFolders works out the folder a command writes to relative to the repository, so that it is skipped when finding modules
+// output is the folder given to the command, relative to the current directory, it is left out when empty or not inside the repository
+func skipFolders(repo string, output string) ([]string, error) {
+	if output == "" {
+		return nil, nil
+	}
+	absRepo, err := filepath.Abs(repo)
+	if err != nil {
+		return nil, err
+	}
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absRepo, absOutput)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+	return []string{filepath.ToSlash(rel)}, nil
+}
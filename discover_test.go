@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"modules/**", "modules", true},
+		{"modules/**", "modules/aws/vpc", true},
+		{"modules/**", "platform/modules", false},
+		{"**/examples", "modules/vpc/examples", true},
+		{"**/examples", "examples", true},
+		{"**/examples", "modules/vpc/examples/basic", false},
+		{"**/examples/**", "modules/vpc/examples/basic", true},
+		{"modules/**/vpc", "modules/vpc", true},
+		{"modules/**/vpc", "modules/aws/eu/vpc", true},
+		{"modules/aws-*", "modules/aws-vpc", true},
+		{"modules/aws-*", "modules/aws-vpc/modules/subnet", false},
+		{"modules/*/vpc", "modules/aws/vpc", true},
+		{"/modules/vpc/", "modules/vpc", true},
+		{"**", "modules/aws/vpc", true},
+		{"modules/[", "modules/x", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.name); got != c.want {
+			t.Errorf("matchGlob(%q, %q) got %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestFindModuleFolders(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	for _, file := range []string{
+		"main.tf",
+		"examples/complete/main.tf",
+		"modules/vpc/main.tf",
+		"modules/vpc/examples/basic/main.tf",
+		"modules/vpc/modules/subnet/main.tf",
+		"modules/aws/iam/main.tf",
+		"modules/.terraform/cached/main.tf",
+		"modules/old/legacy/main.tf",
+		"modules/docs/README.md",
+		"platform/modules/dns/main.tf",
+	} {
+		if err := writeOutputFile(filepath.Join(repo, filepath.FromSlash(file)), []byte("\n")); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	cases := []struct {
+		name  string
+		roots []string
+		opts  scanOptions
+		want  []string
+	}{
+		{"nested", []string{"modules"}, scanOptions{}, []string{"modules/aws/iam", "modules/old/legacy", "modules/vpc", "modules/vpc/modules/subnet"}},
+		{"excluded parent hides its children", []string{"modules"}, scanOptions{exclude: []string{"modules/old"}}, []string{"modules/aws/iam", "modules/vpc", "modules/vpc/modules/subnet"}},
+		{"several roots", []string{"modules", "platform/modules"}, scanOptions{}, []string{"modules/aws/iam", "modules/old/legacy", "modules/vpc", "modules/vpc/modules/subnet", "platform/modules/dns"}},
+		{"overlapping roots", []string{"modules/vpc", " modules"}, scanOptions{}, []string{"modules/aws/iam", "modules/old/legacy", "modules/vpc", "modules/vpc/modules/subnet"}},
+		{"whole repository", []string{"."}, scanOptions{}, []string{"modules/aws/iam", "modules/old/legacy", "modules/vpc", "modules/vpc/modules/subnet", "platform/modules/dns"}},
+		{"relative root", []string{"platform/../modules/aws"}, scanOptions{}, []string{"modules/aws/iam"}},
+		{"include", []string{"modules"}, scanOptions{include: []string{"**/vpc", "modules/aws/**"}}, []string{"modules/aws/iam", "modules/vpc"}},
+		{"output folders", []string{"."}, scanOptions{skip: []string{"platform", "modules/old"}}, []string{"modules/aws/iam", "modules/vpc", "modules/vpc/modules/subnet"}},
+		{"include and exclude", []string{"modules"}, scanOptions{include: []string{"modules/**"}, exclude: []string{"**/modules/subnet"}}, []string{"modules/aws/iam", "modules/old/legacy", "modules/vpc"}},
+	}
+	for _, c := range cases {
+		got, err := findModuleFolders(repo, c.roots, c.opts)
+		if err != nil {
+			t.Fatalf("%s: Issue %q", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSkipFolders(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	cases := []struct {
+		output string
+		want   []string
+	}{
+		{"", nil},
+		{filepath.Join(repo, "site"), []string{"site"}},
+		{filepath.Join(repo, "docs", "modules"), []string{"docs/modules"}},
+		{filepath.Join(repo, "modules", "..", "out"), []string{"out"}},
+		// a folder outside the repository, or the repository itself, holds no modules to skip
+		{repo + "-site", nil},
+		{filepath.Join(repo, ".."), nil},
+		{repo, nil},
+	}
+	for _, c := range cases {
+		got, err := skipFolders(repo, c.output)
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("skipFolders(%q) got %q, want %q", c.output, got, c.want)
+		}
+	}
+
+	// relative outputs are relative to the current directory, which is where they are written
+	if got, err := skipFolders(".", "site"); err != nil || !reflect.DeepEqual(got, []string{"site"}) {
+		t.Errorf("got %q %v for the site in the current directory", got, err)
+	}
+}
+
+func TestFindModuleFoldersSkipsExamples(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	for _, file := range []string{
+		"modules/vpc/main.tf",
+		"modules/vpc/examples/basic/main.tf",
+		"modules/vpc/examples/complete/main.tf",
+		"modules/vpc/modules/subnet/main.tf",
+		"modules/vpc/modules/subnet/examples/basic/main.tf",
+		// an examples folder which is not part of a module is searched like any other
+		"modules/examples/demo/main.tf",
+	} {
+		if err := writeOutputFile(filepath.Join(repo, filepath.FromSlash(file)), []byte("\n")); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	got, err := findModuleFolders(repo, []string{"modules"}, scanOptions{})
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := []string{"modules/examples/demo", "modules/vpc", "modules/vpc/modules/subnet"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
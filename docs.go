@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// docsFormats are the documentation site generators the docs command can write pages for
+var docsFormats = []string{"mkdocs", "docusaurus", "hugo"}
+
+// docsFrontMatter is the front matter at the top of each page, sidebar_label is only used by docusaurus
+type docsFrontMatter struct {
+	Title        string   `yaml:"title"`
+	SidebarLabel string   `yaml:"sidebar_label,omitempty"`
+	Description  string   `yaml:"description,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+}
+
+// docsPage is a page written to the docs folder
+type docsPage struct {
+	file   string
+	source string
+	label  string
+	front  docsFrontMatter
+	text   string
+}
+
+// createDocs writes the README of each module as a page for a documentation site generator, with its navigation config
+// pages are written to docsDir/<module>/index.md, or _index.md for hugo when there are pages below it, with the overview from the root template at docsDir/index.md or _index.md for hugo
+// docsDir is expected to be inside the docs folder of the site, its name is used as the start of the paths in the navigation
+// the terraform files of each module are copied next to its page, so that the links to them work without a web url
+func createDocs(path string, docsDir string, format string, details []CombinedModuleDetails, opts readmeOptions) error {
+	known := false
+	for _, f := range docsFormats {
+		known = known || f == format
+	}
+	if !known {
+		return fmt.Errorf("unknown docs format %q, expected one of %s", format, strings.Join(docsFormats, ", "))
+	}
+
+	// work out where each README is moved to, so that the links between them can be kept
+	indexFile := "index.md"
+	if format == "hugo" {
+		indexFile = "_index.md"
+	}
+	targets := map[string]string{"README.md": indexFile}
+	var pages []docsPage
+	// only the modules with a header get a page, so the others are named without a link
+	graph := newModuleGraph(details)
+	opts.headersOnly = true
+	common := commonFolder(details)
+
+	// hugo does not render pages below a leaf bundle, index.md, so a folder with pages below it needs a section, _index.md
+	names, sections := docsSections(details, common)
+	for _, module := range details {
+		if module.TFDetails.Title == "" {
+			continue
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(module.Folder), common)
+		file := name + "/index.md"
+		if format == "hugo" && sections[name] {
+			file = name + "/_index.md"
+		}
+		source := filepath.ToSlash(module.Folder) + "/README.md"
+		targets[source] = file
+		text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(module, graph, opts))
+		if err != nil {
+			return err
+		}
+		if err := copyModuleSources(path, module, filepath.Join(docsDir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+		pages = append(pages, docsPage{
+			file:   file,
+			source: source,
+			label:  module.TFDetails.Title,
+			front: docsFrontMatter{
+				Title:       module.TFDetails.Title,
+				Description: strings.Join(strings.Fields(module.TFDetails.Desc), " "),
+				Tags:        module.TFDetails.Tags,
+			},
+			text: text,
+		})
+	}
+	text, err := executeTemplate(opts.rootTemplate, newRootTemplateData(details, opts))
+	if err != nil {
+		return err
+	}
+	overview := docsPage{
+		file:   indexFile,
+		source: "README.md",
+		label:  "Overview",
+		front:  docsFrontMatter{Title: opts.title()},
+		text:   text,
+	}
+	pages = append([]docsPage{overview}, pages...)
+	if format == "hugo" {
+		var folders []string
+		for folder := range sections {
+			if !names[folder] {
+				folders = append(folders, folder)
+			}
+		}
+		sort.Strings(folders)
+		for _, folder := range folders {
+			pages = append(pages, docsSectionPage(folder))
+		}
+	}
+
+	for _, p := range pages {
+		if format == "docusaurus" {
+			p.front.SidebarLabel = p.label
+		}
+		front, err := yaml.Marshal(p.front)
+		if err != nil {
+			return err
+		}
+		text := rewriteLinks(p.text, p.source, p.file, targets, format == "hugo")
+		data := "---\n" + string(front) + "---\n\n" + text
+		if err := writeOutputFile(filepath.Join(docsDir, filepath.FromSlash(p.file)), []byte(data)); err != nil {
+			return err
+		}
+	}
+
+	// hugo builds the navigation from the section, which is the _index.md page
+	prefix := filepath.Base(docsDir)
+	switch format {
+	case "mkdocs":
+		return writeOutputFile(filepath.Join(docsDir, "mkdocs-nav.yml"), []byte(mkdocsNav(prefix, opts.title(), pages)))
+	case "docusaurus":
+		return writeOutputFile(filepath.Join(docsDir, "sidebars.modules.js"), []byte(docusaurusSidebar(prefix, opts.title(), pages)))
+	}
+	return nil
+}
+
+// commonFolder is the folder all of the modules are in, ending in a slash, so that their pages can be named by the rest of their folder
+// it is empty when the modules have no folder in common
+func commonFolder(details []CombinedModuleDetails) string {
+	if len(details) == 0 {
+		return ""
+	}
+	common := path.Dir(filepath.ToSlash(details[0].Folder))
+	for _, module := range details {
+		for common != "." && !strings.HasPrefix(filepath.ToSlash(module.Folder), common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "." {
+		return ""
+	}
+	return common + "/"
+}
+
+// docsSections finds the page name of each module and the folders which have pages below them
+func docsSections(details []CombinedModuleDetails, common string) (map[string]bool, map[string]bool) {
+	names := make(map[string]bool)
+	sections := make(map[string]bool)
+	for _, module := range details {
+		if module.TFDetails.Title == "" {
+			continue
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(module.Folder), common)
+		names[name] = true
+		for folder := path.Dir(name); folder != "."; folder = path.Dir(folder) {
+			sections[folder] = true
+		}
+	}
+	return names, sections
+}
+
+// docsSectionPage is the page for a folder with module pages below it which is not a module itself, titled by its name
+func docsSectionPage(folder string) docsPage {
+	return docsPage{
+		file:  folder + "/_index.md",
+		label: path.Base(folder),
+		front: docsFrontMatter{Title: path.Base(folder)},
+	}
+}
+
+// mkdocsNav builds the nav section of mkdocs.yml listing the pages under the title, to be copied into mkdocs.yml
+func mkdocsNav(prefix string, title string, pages []docsPage) string {
+	type navItem map[string]string
+	var items []navItem
+	for _, p := range pages {
+		items = append(items, navItem{p.label: path.Join(prefix, p.file)})
+	}
+	nav := map[string][]map[string][]navItem{
+		"nav": {{title: items}},
+	}
+	data, _ := yaml.Marshal(nav)
+	return "# navigation for the terraform modules, generated by tf-auto-document\n" + string(data)
+}
+
+// docusaurusSidebar builds a sidebar category, labelled with the title, listing the pages, which can be included in sidebars.js
+func docusaurusSidebar(prefix string, title string, pages []docsPage) string {
+	var b strings.Builder
+	b.WriteString("// sidebar for the terraform modules, generated by tf-auto-document\n")
+	b.WriteString("module.exports = {\n")
+	b.WriteString("  type: \"category\",\n")
+	fmt.Fprintf(&b, "  label: %q,\n", title)
+	fmt.Fprintf(&b, "  link: {type: \"doc\", id: %q},\n", docusaurusID(prefix, pages[0].file))
+	b.WriteString("  items: [\n")
+	for _, p := range pages[1:] {
+		fmt.Fprintf(&b, "    %q,\n", docusaurusID(prefix, p.file))
+	}
+	b.WriteString("  ],\n")
+	b.WriteString("};\n")
+	return b.String()
+}
+
+// docusaurusID is the id docusaurus gives to the page in a file, its path without the extension
+func docusaurusID(prefix string, file string) string {
+	return strings.TrimSuffix(path.Join(prefix, file), ".md")
+}
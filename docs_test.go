@@ -0,0 +1,172 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestCreateDocs(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	files := map[string]string{
+		"modules/vpc/variables.tf":           "variable \"cidr\" {}\n",
+		"modules/vpc/examples/basic/main.tf": "module \"vpc\" {}\n",
+		"modules/subnet/main.tf":             "# subnet\n",
+	}
+	for file, text := range files {
+		if err := writeOutputFile(filepath.Join(repo, filepath.FromSlash(file)), []byte(text)); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	details := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc"}}},
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{
+			Title:     "vpc",
+			Variables: []parser.VariableDetails{{Name: "cidr", DataType: "string", Location: parser.SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 1}}},
+			Examples:  []parser.ExampleDetails{{Name: "basic", Folder: "examples/basic", Files: []string{"main.tf"}, Main: "module \"vpc\" {}\n"}},
+		}},
+		{Folder: "modules/nohdr", TFDetails: parser.ModuleDetails{ModuleCalls: []parser.ModuleCallDetails{{Name: "vpc", Source: "../vpc"}}}},
+	}
+	docs := filepath.Join(repo, "site", "docs", "modules")
+	opts := testOptions(t, "markdown")
+	opts.rootTitle = "Platform Modules"
+	if err := createDocs(repo, docs, "mkdocs", details, opts); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	read := func(file string) string {
+		data, err := ioutil.ReadFile(filepath.Join(docs, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		return string(data)
+	}
+
+	page := read("subnet/index.md")
+	if !strings.HasPrefix(page, "---\ntitle: subnet\n") {
+		t.Errorf("page should start with its front matter\n%s", page)
+	}
+	if !strings.Contains(page, "(../vpc/index.md)") {
+		t.Errorf("link to vpc should point at its page\n%s", page)
+	}
+	// the links to where things are defined point next to the page, so the sources have to be there
+	if page := read("vpc/index.md"); !strings.Contains(page, "(variables.tf#L1)") {
+		t.Errorf("variable should link to where it is defined\n%s", page)
+	}
+	// a module without a header has no page, so it is named without a link
+	if page := read("vpc/index.md"); !strings.Contains(page, "* nohdr\n") || strings.Contains(page, "[nohdr]") {
+		t.Errorf("module without a header should be named as plain text\n%s", page)
+	}
+	for file, text := range map[string]string{"vpc/variables.tf": files["modules/vpc/variables.tf"], "vpc/examples/basic/main.tf": files["modules/vpc/examples/basic/main.tf"]} {
+		if got := read(file); got != text {
+			t.Errorf("got %q for the copied %s", got, file)
+		}
+	}
+	if nav := read("mkdocs-nav.yml"); !strings.Contains(nav, "modules/vpc/index.md") || !strings.Contains(nav, "- Platform Modules:\n") {
+		t.Errorf("navigation should list the vpc page under the configured title\n%s", nav)
+	}
+	if page := read("index.md"); !strings.HasPrefix(page, "---\ntitle: Platform Modules\n") {
+		t.Errorf("overview should be titled with the configured title\n%s", page)
+	}
+}
+
+func TestCreateDocsHugo(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	details := []CombinedModuleDetails{
+		{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/aws/vpc/modules/inner", TFDetails: parser.ModuleDetails{Title: "inner"}},
+		{Folder: "modules/azure/vnet", TFDetails: parser.ModuleDetails{Title: "vnet"}},
+	}
+	docs := filepath.Join(repo, "content", "modules")
+	if err := createDocs(repo, docs, "hugo", details, testOptions(t, "markdown")); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+
+	// hugo does not render pages below a leaf bundle, so folders with pages below them have to be sections
+	for _, file := range []string{"_index.md", "aws/_index.md", "aws/vpc/_index.md", "aws/vpc/modules/_index.md", "aws/vpc/modules/inner/index.md", "azure/_index.md", "azure/vnet/index.md"} {
+		if _, err := os.Stat(filepath.Join(docs, filepath.FromSlash(file))); err != nil {
+			t.Errorf("expected %s to be written: %q", file, err)
+		}
+	}
+	for _, file := range []string{"aws/vpc/index.md", "aws/vpc/modules/inner/_index.md"} {
+		if _, err := os.Stat(filepath.Join(docs, filepath.FromSlash(file))); err == nil {
+			t.Errorf("did not expect %s to be written", file)
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(docs, "aws", "_index.md"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if !strings.HasPrefix(string(data), "---\ntitle: aws\n") {
+		t.Errorf("section should be titled by its folder\n%s", data)
+	}
+}
+
+func TestCommonFolder(t *testing.T) {
+	cases := []struct {
+		folders []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"modules/vpc"}, "modules/"},
+		{[]string{"modules/vpc", "modules/subnet"}, "modules/"},
+		{[]string{"modules/aws/vpc", "modules/aws/subnet"}, "modules/aws/"},
+		{[]string{"modules/aws/vpc", "modules/gcp/vpc"}, "modules/"},
+		{[]string{"modules/vpc", "modules/vpc/modules/subnet"}, "modules/"},
+		{[]string{"modules/vpc", "platform/modules/dns"}, ""},
+		{[]string{"vpc", "subnet"}, ""},
+		{[]string{"modules/aws", "modules/aws-extra/vpc"}, "modules/"},
+	}
+	for _, c := range cases {
+		var details []CombinedModuleDetails
+		for _, folder := range c.folders {
+			details = append(details, CombinedModuleDetails{Folder: folder})
+		}
+		if got := commonFolder(details); got != c.want {
+			t.Errorf("commonFolder(%q) got %q, want %q", c.folders, got, c.want)
+		}
+	}
+}
+
+func TestCreateDocsFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docs")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(dir)
+	details := []CombinedModuleDetails{{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}}}
+	opts := testOptions(t, "markdown")
+
+	if err := createDocs(dir, filepath.Join(dir, "docusaurus"), "docusaurus", details, opts); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	sidebar, err := ioutil.ReadFile(filepath.Join(dir, "docusaurus", "sidebars.modules.js"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if !strings.Contains(string(sidebar), "\"docusaurus/vpc/index\"") {
+		t.Errorf("sidebar should list the vpc page\n%s", sidebar)
+	}
+
+	if err := createDocs(dir, filepath.Join(dir, "hugo"), "hugo", details, opts); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "hugo", "_index.md")); err != nil {
+		t.Errorf("hugo overview should be the _index.md page: %v", err)
+	}
+
+	if err := createDocs(dir, filepath.Join(dir, "other"), "jekyll", details, opts); err == nil {
+		t.Errorf("expected an error for an unknown docs format")
+	}
+}
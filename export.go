@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// exportVersion is the version of the export format, it must be changed when a breaking change is made to the format
+const exportVersion = "1"
+
+// exportSchemaURL is where the JSON Schema for the export format is published
+const exportSchemaURL = "https://raw.githubusercontent.com/richardjkendall/tf-auto-document/master/schema/export-v1.schema.json"
+
+// names of the files written by the export command, the extension is added based on the format
+const (
+	exportModuleFile  = "tf-module"
+	exportCatalogFile = "tf-modules-catalog"
+)
+
+// exportCatalog is the document holding every module
+type exportCatalog struct {
+	Schema  string         `json:"$schema" yaml:"$schema"`
+	Version string         `json:"version" yaml:"version"`
+	Modules []exportModule `json:"modules" yaml:"modules"`
+}
+
+// exportDocument is the document holding a single module
+type exportDocument struct {
+	Schema  string       `json:"$schema" yaml:"$schema"`
+	Version string       `json:"version" yaml:"version"`
+	Module  exportModule `json:"module" yaml:"module"`
+}
+
+// exportModule is the exported form of CombinedModuleDetails
+type exportModule struct {
+	Folder     string           `json:"folder" yaml:"folder"`
+	Title      string           `json:"title" yaml:"title"`
+	Desc       string           `json:"description" yaml:"description"`
+	Status     string           `json:"status,omitempty" yaml:"status,omitempty"`
+	Team       string           `json:"team,omitempty" yaml:"team,omitempty"`
+	Owners     []string         `json:"owners" yaml:"owners"`
+	Tags       []string         `json:"tags" yaml:"tags"`
+	Depends    []string         `json:"depends" yaml:"depends"`
+	Partners   []string         `json:"partners" yaml:"partners"`
+	Deprecated string           `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	ReplacedBy string           `json:"replacedBy,omitempty" yaml:"replacedBy,omitempty"`
+	Header     *exportLocation  `json:"header,omitempty" yaml:"header,omitempty"`
+	Variables  []exportVariable `json:"variables" yaml:"variables"`
+	Outputs    []exportOutput   `json:"outputs" yaml:"outputs"`
+	Resources  []exportResource `json:"resources" yaml:"resources"`
+	Releases   []exportRelease  `json:"releases" yaml:"releases"`
+}
+
+// exportLocation is the exported form of parser.SourceLocation
+type exportLocation struct {
+	File      string `json:"file" yaml:"file"`
+	StartLine int    `json:"startLine" yaml:"startLine"`
+	EndLine   int    `json:"endLine" yaml:"endLine"`
+}
+
+// exportVariable is the exported form of parser.VariableDetails
+// the default of a sensitive variable is left out so that it is not published
+type exportVariable struct {
+	Name      string         `json:"name" yaml:"name"`
+	Desc      string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Type      string         `json:"type,omitempty" yaml:"type,omitempty"`
+	Default   interface{}    `json:"default,omitempty" yaml:"default,omitempty"`
+	Sensitive bool           `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
+	Doc       string         `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Location  exportLocation `json:"location" yaml:"location"`
+}
+
+// exportOutput is the exported form of parser.OutputDetails
+type exportOutput struct {
+	Name     string         `json:"name" yaml:"name"`
+	Desc     string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Doc      string         `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Location exportLocation `json:"location" yaml:"location"`
+}
+
+// exportResource is the exported form of parser.ResourceDetails
+type exportResource struct {
+	Mode     string         `json:"mode" yaml:"mode"`
+	Type     string         `json:"type" yaml:"type"`
+	Name     string         `json:"name" yaml:"name"`
+	Location exportLocation `json:"location" yaml:"location"`
+}
+
+// exportRelease is a tagged commit which changed the module
+type exportRelease struct {
+	Tag     string `json:"tag" yaml:"tag"`
+	Commit  string `json:"commit" yaml:"commit"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// newExportLocation converts a source location for export
+func newExportLocation(loc parser.SourceLocation) exportLocation {
+	return exportLocation{File: loc.File, StartLine: loc.StartLine, EndLine: loc.EndLine}
+}
+
+// nonNil makes sure a list is exported as an empty list rather than null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// newExportModule converts the details of a module for export
+func newExportModule(details CombinedModuleDetails) exportModule {
+	m := details.TFDetails
+	e := exportModule{
+		Folder:     details.Folder,
+		Title:      m.Title,
+		Desc:       m.Desc,
+		Status:     m.Status,
+		Team:       m.Team,
+		Owners:     nonNil(m.Owners),
+		Tags:       nonNil(m.Tags),
+		Depends:    nonNil(m.Depends),
+		Partners:   nonNil(m.Partners),
+		Deprecated: m.Deprecated,
+		ReplacedBy: m.ReplacedBy,
+		Variables:  []exportVariable{},
+		Outputs:    []exportOutput{},
+		Resources:  []exportResource{},
+		Releases:   []exportRelease{},
+	}
+	if m.HeaderLoc.File != "" {
+		loc := newExportLocation(m.HeaderLoc)
+		e.Header = &loc
+	}
+	for _, v := range m.Variables {
+		variable := exportVariable{
+			Name:      v.Name,
+			Desc:      v.Desc,
+			Type:      v.DataType,
+			Sensitive: v.Sensitive,
+			Doc:       v.Doc,
+			Location:  newExportLocation(v.Location),
+		}
+		if !v.Sensitive {
+			variable.Default = v.DefValue
+		}
+		e.Variables = append(e.Variables, variable)
+	}
+	for _, o := range m.Outputs {
+		e.Outputs = append(e.Outputs, exportOutput{
+			Name:     o.Name,
+			Desc:     o.Desc,
+			Doc:      o.Doc,
+			Location: newExportLocation(o.Location),
+		})
+	}
+	for _, r := range m.Resources {
+		e.Resources = append(e.Resources, exportResource{
+			Mode:     r.Mode,
+			Type:     r.Type,
+			Name:     r.Name,
+			Location: newExportLocation(r.Location),
+		})
+	}
+	for _, c := range details.GitDetails {
+		if c.Tag != "" {
+			e.Releases = append(e.Releases, exportRelease{
+				Tag:     c.Tag,
+				Commit:  c.Hash,
+				Message: strings.Trim(c.Message, "\r\n"),
+			})
+		}
+	}
+	return e
+}
+
+// marshalExport serializes an export document as json or yaml
+func marshalExport(doc interface{}, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		return yaml.Marshal(doc)
+	}
+	return nil, fmt.Errorf("unknown export format %q, expected json or yaml", format)
+}
+
+// exportModules writes the details of the modules as json or yaml
+// mode is module for a file in each module folder, catalog for one file in the repository folder, or both
+func exportModules(path string, details []CombinedModuleDetails, format string, mode string) error {
+	if mode != "module" && mode != "catalog" && mode != "both" {
+		return fmt.Errorf("unknown export mode %q, expected module, catalog or both", mode)
+	}
+	catalog := exportCatalog{Schema: exportSchemaURL, Version: exportVersion, Modules: []exportModule{}}
+	for _, m := range details {
+		e := newExportModule(m)
+		catalog.Modules = append(catalog.Modules, e)
+		if mode == "catalog" {
+			continue
+		}
+		data, err := marshalExport(exportDocument{Schema: exportSchemaURL, Version: exportVersion, Module: e}, format)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(path, m.Folder, exportModuleFile+"."+format)
+		fmt.Fprintf(progress, "Exporting %s\n", file)
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+	}
+	if mode == "module" {
+		return nil
+	}
+	data, err := marshalExport(catalog, format)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(path, exportCatalogFile+"."+format)
+	fmt.Fprintf(progress, "Exporting %s\n", file)
+	return ioutil.WriteFile(file, data, 0644)
+}
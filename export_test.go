@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+	"github.com/richardjkendall/tf-auto-document/scangit"
+)
+
+// schemaChecker checks json documents against the parts of JSON Schema draft-07 which the export schema uses
+// it fails on any keyword it does not know, so that a change to the schema cannot quietly go unchecked
+type schemaChecker struct {
+	root map[string]interface{}
+}
+
+// schemaAnnotations are keywords which do not affect validation
+var schemaAnnotations = map[string]bool{"$schema": true, "$id": true, "title": true, "description": true, "definitions": true}
+
+// check returns the problems with a value, path is where the value is in the document
+func (c schemaChecker) check(schema map[string]interface{}, value interface{}, path string) []string {
+	var problems []string
+	var keywords []string
+	for k := range schema {
+		keywords = append(keywords, k)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		rule := schema[keyword]
+		switch keyword {
+		case "$ref":
+			name := strings.TrimPrefix(rule.(string), "#/definitions/")
+			definition := c.root["definitions"].(map[string]interface{})[name].(map[string]interface{})
+			problems = append(problems, c.check(definition, value, path)...)
+		case "type":
+			if !hasType(value, rule.(string)) {
+				problems = append(problems, fmt.Sprintf("%s: %v is not of type %s", path, value, rule))
+			}
+		case "const":
+			if value != rule {
+				problems = append(problems, fmt.Sprintf("%s: %v is not %v", path, value, rule))
+			}
+		case "enum":
+			found := false
+			for _, e := range rule.([]interface{}) {
+				found = found || e == value
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, rule))
+			}
+		case "minimum":
+			if n, ok := value.(float64); ok && n < rule.(float64) {
+				problems = append(problems, fmt.Sprintf("%s: %v is less than %v", path, value, rule))
+			}
+		case "required":
+			object, _ := value.(map[string]interface{})
+			for _, name := range rule.([]interface{}) {
+				if _, ok := object[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: %s is missing", path, name))
+				}
+			}
+		case "properties":
+			object, _ := value.(map[string]interface{})
+			for name, property := range rule.(map[string]interface{}) {
+				if v, ok := object[name]; ok {
+					problems = append(problems, c.check(property.(map[string]interface{}), v, path+"."+name)...)
+				}
+			}
+		case "additionalProperties":
+			properties, _ := schema["properties"].(map[string]interface{})
+			object, _ := value.(map[string]interface{})
+			for name := range object {
+				if _, ok := properties[name]; !ok && rule == false {
+					problems = append(problems, fmt.Sprintf("%s: %s is not allowed", path, name))
+				}
+			}
+		case "items":
+			list, _ := value.([]interface{})
+			for i, item := range list {
+				problems = append(problems, c.check(rule.(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		case "oneOf":
+			matches := 0
+			for _, option := range rule.([]interface{}) {
+				if len(c.check(option.(map[string]interface{}), value, path)) == 0 {
+					matches++
+				}
+			}
+			if matches != 1 {
+				problems = append(problems, fmt.Sprintf("%s: matches %d of the oneOf schemas, not 1", path, matches))
+			}
+		default:
+			if !schemaAnnotations[keyword] {
+				panic("the test does not know the schema keyword " + keyword)
+			}
+		}
+	}
+	return problems
+}
+
+// hasType checks if a value decoded from json is of a JSON Schema type
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	panic("the test does not know the schema type " + t)
+}
+
+// loadSchemaChecker reads the export schema
+func loadSchemaChecker(t *testing.T) schemaChecker {
+	data, err := ioutil.ReadFile("schema/export-v" + exportVersion + ".schema.json")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	var c schemaChecker
+	if err := json.Unmarshal(data, &c.root); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	return c
+}
+
+// exportTestModules are modules with every field which is exported set, and one with nothing set
+var exportTestModules = []CombinedModuleDetails{
+	{
+		Folder: "modules/vpc",
+		TFDetails: parser.ModuleDetails{
+			Title:      "vpc",
+			Desc:       "Creates a vpc.",
+			Status:     parser.StatusDeprecated,
+			Team:       "network",
+			Owners:     []string{"alice"},
+			Tags:       []string{"aws"},
+			Depends:    []string{"iam"},
+			Partners:   []string{"subnet"},
+			Deprecated: "Use vpc2.",
+			ReplacedBy: "vpc2",
+			HeaderLoc:  parser.SourceLocation{File: "main.tf", StartLine: 1, EndLine: 5},
+			Variables: []parser.VariableDetails{
+				{Name: "cidr", Desc: "The cidr.", DataType: "string", Def: "10.0.0.0/16", DefValue: "10.0.0.0/16", Doc: "More about the cidr.", Location: parser.SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 4}},
+				{Name: "tags", DataType: "map(string)", Def: "{a=1, b=2}", DefValue: map[string]interface{}{"a": "1", "b": "2"}, Location: parser.SourceLocation{File: "variables.tf", StartLine: 6, EndLine: 9}},
+				{Name: "ports", DataType: "list(number)", Def: "[80, 443]", DefValue: []interface{}{int64(80), int64(443)}, Location: parser.SourceLocation{File: "variables.tf", StartLine: 11, EndLine: 14}},
+				{Name: "enabled", DataType: "bool", Def: "false", DefValue: false, Location: parser.SourceLocation{File: "variables.tf", StartLine: 16, EndLine: 19}},
+				{Name: "password", DataType: "string", Def: "hunter2", DefValue: "hunter2", Sensitive: true, Location: parser.SourceLocation{File: "variables.tf", StartLine: 21, EndLine: 25}},
+			},
+			Outputs: []parser.OutputDetails{
+				{Name: "id", Desc: "The id.", Doc: "More about the id.", Location: parser.SourceLocation{File: "outputs.tf", StartLine: 1, EndLine: 3}},
+			},
+			Resources: []parser.ResourceDetails{
+				{Mode: "resource", Type: "aws_vpc", Name: "this", Location: parser.SourceLocation{File: "main.tf", StartLine: 7, EndLine: 9}},
+				{Mode: "data", Type: "aws_region", Name: "current", Location: parser.SourceLocation{File: "main.tf", StartLine: 11, EndLine: 11}},
+			},
+		},
+		GitDetails: []scangit.GitCommit{
+			{Hash: "b8de46ba9b6b0e4ad4ba0153dea2019fa0833311", Tag: "v1.0.0", Message: "initial\n"},
+			{Hash: "c8de46ba9b6b0e4ad4ba0153dea2019fa0833311", Message: "untagged\n"},
+		},
+	},
+	{Folder: "modules/empty"},
+}
+
+func TestExportMatchesSchema(t *testing.T) {
+	c := loadSchemaChecker(t)
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, m := range exportTestModules {
+		if err := os.MkdirAll(filepath.Join(dir, m.Folder), 0755); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	if err := exportModules(dir, exportTestModules, "json", "both"); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	files := []string{exportCatalogFile + ".json"}
+	for _, m := range exportTestModules {
+		files = append(files, filepath.Join(m.Folder, exportModuleFile+".json"))
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		for _, p := range c.check(c.root, doc, file) {
+			t.Error(p)
+		}
+	}
+}
+
+func TestExportDefaults(t *testing.T) {
+	e := newExportModule(exportTestModules[0])
+	want := map[string]string{
+		"cidr":     `"10.0.0.0/16"`,
+		"tags":     `{"a":"1","b":"2"}`,
+		"ports":    `[80,443]`,
+		"enabled":  `false`,
+		"password": `null`,
+	}
+	for _, v := range e.Variables {
+		data, err := json.Marshal(v.Default)
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if string(data) != want[v.Name] {
+			t.Errorf("got %s for the default of %s, expected %s", data, v.Name, want[v.Name])
+		}
+	}
+
+	// the default keeps its type in yaml too, and is left out for sensitive variables
+	data, err := marshalExport(e.Variables, "yaml")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, text := range []string{"  default: 10.0.0.0/16\n", "  - 80\n", "  default: false\n", "  sensitive: true\n"} {
+		if !strings.Contains(string(data), text) {
+			t.Errorf("expected %q in\n%s", text, data)
+		}
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("the default of a sensitive variable should not be exported\n%s", data)
+	}
+}
+
+func TestSchemaCheckerFindsProblems(t *testing.T) {
+	c := loadSchemaChecker(t)
+	doc := map[string]interface{}{
+		"version": "1",
+		"module": map[string]interface{}{
+			"folder": "modules/vpc", "title": "vpc", "description": "", "owners": []interface{}{}, "tags": []interface{}{},
+			"depends": []interface{}{}, "partners": []interface{}{}, "variables": []interface{}{}, "outputs": []interface{}{},
+			"resources": []interface{}{map[string]interface{}{"mode": "managed", "type": "aws_vpc", "name": "this",
+				"location": map[string]interface{}{"file": "main.tf", "startLine": 1.0, "endLine": 2.0}}},
+			"releases": []interface{}{},
+			"extra":    true,
+		},
+	}
+	if problems := c.check(c.root, doc, "doc"); len(problems) == 0 {
+		t.Errorf("an unknown property and resource mode should not match the schema")
+	}
+}
@@ -10,4 +10,5 @@ require (
 	github.com/hashicorp/hcl/v2 v2.4.0
 	github.com/zclconf/go-cty v1.4.0
 	gopkg.in/src-d/go-git.v4 v4.13.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// moduleGraph holds how the modules relate to each other
+// modules in the repository are known by their folder, relative to the repository, and other modules by the name they are given in depends and partners
+type moduleGraph struct {
+	nodes []string
+	// depends are edges from a module to a module it depends on
+	depends [][2]string
+	// partners are edges between modules which work with each other, the names in each are sorted
+	partners [][2]string
+	// calls are edges from a module to a module in the repository it uses in a module block, which are not declared in depends
+	calls [][2]string
+	// labels are the names the modules in the repository are shown with, keyed by folder
+	labels map[string]string
+	// named maps the folder name of each module in the repository to the folders of the modules with that name
+	named map[string][]string
+	// headers are the folders of the modules in the repository which have a header
+	headers map[string]bool
+}
+
+// moduleLabels works out the name each module folder is shown with, which is the folder name
+// or the whole folder when another module has the same folder name, so that no two modules look the same
+func moduleLabels(folders []string) map[string]string {
+	count := make(map[string]int)
+	for _, folder := range folders {
+		count[path.Base(folder)]++
+	}
+	labels := make(map[string]string)
+	for _, folder := range folders {
+		labels[folder] = path.Base(folder)
+		if count[path.Base(folder)] > 1 {
+			labels[folder] = folder
+		}
+	}
+	return labels
+}
+
+// newModuleGraph builds the graph of the depends and partners of the modules
+// modules which are depended on but not in the repository are included so that the graph shows them
+// the module blocks of modules without a header are included too, as they still use the modules they call
+func newModuleGraph(details []CombinedModuleDetails) moduleGraph {
+	g := moduleGraph{named: make(map[string][]string), headers: make(map[string]bool)}
+	var folders []string
+	for _, module := range details {
+		folder := filepath.ToSlash(module.Folder)
+		folders = append(folders, folder)
+		g.headers[folder] = module.TFDetails.Title != ""
+		g.named[path.Base(folder)] = append(g.named[path.Base(folder)], folder)
+	}
+	g.labels = moduleLabels(folders)
+	nodes := make(map[string]bool)
+	depends := make(map[[2]string]bool)
+	partners := make(map[[2]string]bool)
+	calls := make(map[[2]string]bool)
+	for _, module := range details {
+		from := filepath.ToSlash(module.Folder)
+		nodes[from] = true
+		for _, name := range module.TFDetails.Depends {
+			to, _ := g.resolve(name)
+			nodes[to] = true
+			depends[[2]string{from, to}] = true
+		}
+		for _, name := range module.TFDetails.Partners {
+			to, _ := g.resolve(name)
+			nodes[to] = true
+			edge := [2]string{from, to}
+			if to < from {
+				edge = [2]string{to, from}
+			}
+			partners[edge] = true
+		}
+		for _, call := range module.TFDetails.ModuleCalls {
+			to := path.Join(from, call.Source)
+			if _, ok := g.labels[to]; !ok || !isLocalSource(call.Source) || to == from {
+				continue
+			}
+			nodes[to] = true
+			calls[[2]string{from, to}] = true
+		}
+	}
+	for e := range calls {
+		if depends[e] {
+			delete(calls, e)
+		}
+	}
+	for n := range nodes {
+		g.nodes = append(g.nodes, n)
+	}
+	sort.Strings(g.nodes)
+	g.depends = sortedEdges(depends)
+	g.partners = sortedEdges(partners)
+	g.calls = sortedEdges(calls)
+	return g
+}
+
+// resolve finds the module a name in depends, partners or replaced-by refers to, which is either its folder name or its folder
+// ok is false when the name is not a module in the repository, or is the folder name of more than one, and the name is returned as it is
+func (g moduleGraph) resolve(name string) (string, bool) {
+	if _, ok := g.labels[name]; ok {
+		return name, true
+	}
+	if folders := g.named[name]; len(folders) == 1 {
+		return folders[0], true
+	}
+	return name, false
+}
+
+// label is the name a module is shown with
+func (g moduleGraph) label(node string) string {
+	if label, ok := g.labels[node]; ok {
+		return label
+	}
+	return node
+}
+
+// isLocalSource checks if the source of a module block is a path, rather than a registry or remote source
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// sortedEdges lists a set of edges in order
+func sortedEdges(set map[[2]string]bool) [][2]string {
+	var edges [][2]string
+	for e := range set {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges
+}
+
+// neighbourhood is the part of the graph around a module, its dependencies, the modules which depend on it and its partners
+func (g moduleGraph) neighbourhood(node string) moduleGraph {
+	return g.around(map[string]bool{node: true})
+}
+
+// around is the part of the graph made up of the selected modules and the edges to and from them
+func (g moduleGraph) around(selected map[string]bool) moduleGraph {
+	n := moduleGraph{labels: g.labels, named: g.named, headers: g.headers}
+	nodes := make(map[string]bool)
+	for name := range selected {
+		nodes[name] = true
+	}
+	touching := func(edges [][2]string) [][2]string {
+		var r [][2]string
+		for _, e := range edges {
+			if selected[e[0]] || selected[e[1]] {
+				r = append(r, e)
+				nodes[e[0]], nodes[e[1]] = true, true
+			}
+		}
+		return r
+	}
+	n.depends = touching(g.depends)
+	n.partners = touching(g.partners)
+	n.calls = touching(g.calls)
+	for _, node := range g.nodes {
+		if nodes[node] {
+			n.nodes = append(n.nodes, node)
+		}
+	}
+	return n
+}
+
+// mermaid formats the graph as a mermaid flowchart, with depends and calls as arrows and partners as dotted lines
+// current is the module to highlight, if any, returns an empty string if there are no edges to draw
+func (g moduleGraph) mermaid(current string) string {
+	if len(g.depends) == 0 && len(g.partners) == 0 && len(g.calls) == 0 {
+		return ""
+	}
+	ids := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range g.nodes {
+		ids[node] = fmt.Sprintf("m%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node], strings.Replace(g.label(node), "\"", "#quot;", -1))
+	}
+	for _, e := range sortedEdges(g.dependsAndCalls()) {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e[0]], ids[e[1]])
+	}
+	for _, e := range g.partners {
+		fmt.Fprintf(&b, "  %s -.- %s\n", ids[e[0]], ids[e[1]])
+	}
+	if id, ok := ids[current]; ok {
+		b.WriteString("  classDef current stroke-width:3px\n")
+		fmt.Fprintf(&b, "  class %s current\n", id)
+	}
+	return b.String()
+}
+
+// dependents lists the modules which depend on a module, whether declared or from module blocks
+func (g moduleGraph) dependents(node string) []string {
+	var r []string
+	for _, e := range sortedEdges(g.dependsAndCalls()) {
+		if e[1] == node && !containsName(r, e[0]) {
+			r = append(r, e[0])
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+// dependsAndCalls is the set of edges from modules to the modules they depend on, whether declared or from module blocks
+func (g moduleGraph) dependsAndCalls() map[[2]string]bool {
+	edges := make(map[[2]string]bool)
+	for _, e := range g.depends {
+		edges[e] = true
+	}
+	for _, e := range g.calls {
+		edges[e] = true
+	}
+	return edges
+}
+
+// dotQuote quotes a string for use as an id or label in the dot language
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+// dot formats the graph in the graphviz dot language
+// declared depends are solid arrows, dependencies only found from module blocks are dashed and partners are dotted lines
+// modules which are not in the repository are drawn with a dashed border
+func (g moduleGraph) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph modules {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	id := func(node string) string {
+		return dotQuote(g.label(node))
+	}
+	for _, node := range g.nodes {
+		if _, ok := g.labels[node]; ok {
+			fmt.Fprintf(&b, "  %s;\n", id(node))
+		} else {
+			fmt.Fprintf(&b, "  %s [style=dashed];\n", id(node))
+		}
+	}
+	for _, e := range g.depends {
+		fmt.Fprintf(&b, "  %s -> %s;\n", id(e[0]), id(e[1]))
+	}
+	for _, e := range g.calls {
+		fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", id(e[0]), id(e[1]))
+	}
+	for _, e := range g.partners {
+		fmt.Fprintf(&b, "  %s -> %s [style=dotted, dir=none];\n", id(e[0]), id(e[1]))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// resourceShapes are the dot shapes used for each kind of item in the graph of a module
+var resourceShapes = map[string]string{
+	"var":    "ellipse",
+	"local":  "note",
+	"output": "cds",
+	"module": "component",
+	"data":   "box, style=dashed",
+}
+
+// resourceDot formats the graph of references between the variables, locals, resources and outputs in the modules
+// each module is drawn as a cluster, with arrows from each item to the items it refers to
+func resourceDot(details []CombinedModuleDetails) string {
+	var b strings.Builder
+	b.WriteString("digraph resources {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	var folders []string
+	for _, module := range details {
+		folders = append(folders, filepath.ToSlash(module.Folder))
+	}
+	labels := moduleLabels(folders)
+	for i, module := range details {
+		m := module.TFDetails
+		folder := filepath.ToSlash(module.Folder)
+		id := func(address string) string {
+			return dotQuote(labels[folder] + "/" + address)
+		}
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(labels[folder]))
+		var addresses []string
+		for _, v := range m.Variables {
+			addresses = append(addresses, "var."+v.Name)
+		}
+		for _, l := range m.Locals {
+			addresses = append(addresses, "local."+l.Name)
+		}
+		for _, r := range m.Resources {
+			if r.Mode == "data" {
+				addresses = append(addresses, "data."+r.Type+"."+r.Name)
+			} else {
+				addresses = append(addresses, r.Type+"."+r.Name)
+			}
+		}
+		for _, c := range m.ModuleCalls {
+			addresses = append(addresses, "module."+c.Name)
+		}
+		for _, o := range m.Outputs {
+			addresses = append(addresses, "output."+o.Name)
+		}
+		for _, address := range addresses {
+			shape := "box"
+			if s, ok := resourceShapes[strings.Split(address, ".")[0]]; ok {
+				shape = s
+			}
+			fmt.Fprintf(&b, "    %s [label=%s, shape=%s];\n", id(address), dotQuote(address), shape)
+		}
+		var from []string
+		for address := range m.References {
+			from = append(from, address)
+		}
+		sort.Strings(from)
+		for _, address := range from {
+			for _, to := range m.References[address] {
+				fmt.Fprintf(&b, "    %s -> %s;\n", id(address), id(to))
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphTypes are the graphs the graph command can draw
+var graphTypes = []string{"modules", "resources"}
+
+// createGraph draws a graph of the modules in the dot language
+// modules is a comma separated list of module names or folders and tag the tag a module must have, to limit the graph to matching modules
+// the modules graph includes the edges to and from the matching modules, the resources graph has a cluster for each one
+func createGraph(details []CombinedModuleDetails, graphType string, modules string, tag string) (string, error) {
+	var names []string
+	if modules != "" {
+		names = strings.Split(modules, ",")
+	}
+	var matching []CombinedModuleDetails
+	selected := make(map[string]bool)
+	for _, module := range details {
+		folder := filepath.ToSlash(module.Folder)
+		if len(names) > 0 && !containsName(names, path.Base(folder)) && !containsName(names, folder) {
+			continue
+		}
+		if tag != "" && !containsName(module.TFDetails.Tags, tag) {
+			continue
+		}
+		matching = append(matching, module)
+		selected[folder] = true
+	}
+	if len(matching) == 0 {
+		return "", fmt.Errorf("no modules match the filters")
+	}
+	switch graphType {
+	case "modules":
+		g := newModuleGraph(details)
+		if len(names) > 0 || tag != "" {
+			g = g.around(selected)
+		}
+		return g.dot(), nil
+	case "resources":
+		return resourceDot(matching), nil
+	}
+	return "", fmt.Errorf("unknown graph type %q, expected one of %s", graphType, strings.Join(graphTypes, ", "))
+}
+
+// containsName checks if a list of names includes a name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+// graphTestModules are modules which depend on, call and work with each other, and one which is on its own
+var graphTestModules = []CombinedModuleDetails{
+	{Folder: "modules/app", TFDetails: parser.ModuleDetails{
+		Title:       "app",
+		Tags:        []string{"web"},
+		Depends:     []string{"subnet"},
+		ModuleCalls: []parser.ModuleCallDetails{{Name: "db", Source: "../db"}},
+	}},
+	{Folder: "modules/db", TFDetails: parser.ModuleDetails{Title: "db", Partners: []string{"app"}}},
+	{Folder: "modules/dns", TFDetails: parser.ModuleDetails{Title: "dns"}},
+	{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc"}, Partners: []string{"nat"}}},
+	{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+}
+
+func TestNeighbourhood(t *testing.T) {
+	g := newModuleGraph(graphTestModules)
+	n := g.neighbourhood("modules/subnet")
+	if want := []string{"modules/app", "modules/subnet", "modules/vpc", "nat"}; !reflect.DeepEqual(n.nodes, want) {
+		t.Errorf("got nodes %q, want %q", n.nodes, want)
+	}
+	if want := [][2]string{{"modules/app", "modules/subnet"}, {"modules/subnet", "modules/vpc"}}; !reflect.DeepEqual(n.depends, want) {
+		t.Errorf("got depends %q, want %q", n.depends, want)
+	}
+	if want := [][2]string{{"modules/subnet", "nat"}}; !reflect.DeepEqual(n.partners, want) {
+		t.Errorf("got partners %q, want %q", n.partners, want)
+	}
+	if len(n.calls) != 0 {
+		t.Errorf("got calls %q which do not touch subnet", n.calls)
+	}
+	if n := g.neighbourhood("modules/dns"); !reflect.DeepEqual(n.nodes, []string{"modules/dns"}) || len(n.depends)+len(n.partners)+len(n.calls) != 0 {
+		t.Errorf("got %+v for a module on its own", n)
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	g := newModuleGraph(graphTestModules)
+	want := `flowchart LR
+  m0["app"]
+  m1["subnet"]
+  m2["vpc"]
+  m3["nat"]
+  m0 --> m1
+  m1 --> m2
+  m1 -.- m3
+  classDef current stroke-width:3px
+  class m1 current
+`
+	if got := g.neighbourhood("modules/subnet").mermaid("modules/subnet"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// calls are drawn as arrows like depends, and nothing is highlighted without a current module
+	want = `flowchart LR
+  m0["app"]
+  m1["db"]
+  m2["dns"]
+  m3["subnet"]
+  m4["vpc"]
+  m5["nat"]
+  m0 --> m1
+  m0 --> m3
+  m3 --> m4
+  m0 -.- m1
+  m3 -.- m5
+`
+	if got := g.mermaid(""); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := g.neighbourhood("modules/dns").mermaid("modules/dns"); got != "" {
+		t.Errorf("a module with nothing around it should have no graph, got\n%s", got)
+	}
+}
+
+func TestModuleWithoutHeaderCalls(t *testing.T) {
+	details := append([]CombinedModuleDetails{
+		{Folder: "modules/nohdr", TFDetails: parser.ModuleDetails{ModuleCalls: []parser.ModuleCallDetails{{Name: "subnet", Source: "../subnet"}}}},
+	}, graphTestModules...)
+	g := newModuleGraph(details)
+	if want := [][2]string{{"modules/app", "modules/db"}, {"modules/nohdr", "modules/subnet"}}; !reflect.DeepEqual(g.calls, want) {
+		t.Errorf("got calls %q, want %q", g.calls, want)
+	}
+	if got, want := g.dependents("modules/subnet"), []string{"modules/app", "modules/nohdr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got dependents %q, want %q", got, want)
+	}
+}
+
+func TestModuleLabels(t *testing.T) {
+	got := moduleLabels([]string{"modules/aws/vpc", "modules/gcp/vpc", "modules/subnet", "platform/dns"})
+	want := map[string]string{
+		"modules/aws/vpc": "modules/aws/vpc",
+		"modules/gcp/vpc": "modules/gcp/vpc",
+		"modules/subnet":  "subnet",
+		"platform/dns":    "dns",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestModuleGraphWithSharedNames(t *testing.T) {
+	details := []CombinedModuleDetails{
+		{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/gcp/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"modules/aws/vpc", "vpc", "iam"}}},
+	}
+	g := newModuleGraph(details)
+	resolves := []struct {
+		name   string
+		folder string
+		ok     bool
+	}{
+		{"subnet", "modules/subnet", true},
+		{"modules/subnet", "modules/subnet", true},
+		{"modules/gcp/vpc", "modules/gcp/vpc", true},
+		{"vpc", "vpc", false},
+		{"iam", "iam", false},
+	}
+	for _, c := range resolves {
+		if folder, ok := g.resolve(c.name); folder != c.folder || ok != c.ok {
+			t.Errorf("resolve(%q) got %q %v, want %q %v", c.name, folder, ok, c.folder, c.ok)
+		}
+	}
+	wantDepends := [][2]string{{"modules/subnet", "iam"}, {"modules/subnet", "modules/aws/vpc"}, {"modules/subnet", "vpc"}}
+	if !reflect.DeepEqual(g.depends, wantDepends) {
+		t.Errorf("got depends %q, want %q", g.depends, wantDepends)
+	}
+	if got := g.dependents("modules/aws/vpc"); !reflect.DeepEqual(got, []string{"modules/subnet"}) {
+		t.Errorf("got dependents %q", got)
+	}
+	if got := g.dependents("modules/gcp/vpc"); len(got) != 0 {
+		t.Errorf("got dependents %q of the vpc which is not depended on", got)
+	}
+
+	problems := findingLines(validateDependencies(details, g))
+	want := []string{
+		"modules/aws/vpc: modules modules/aws/vpc, modules/gcp/vpc are all called vpc, use their folders to refer to them (shared-name)",
+		"modules/gcp/vpc: modules modules/aws/vpc, modules/gcp/vpc are all called vpc, use their folders to refer to them (shared-name)",
+		"modules/subnet: depends on vpc could be any of modules/aws/vpc, modules/gcp/vpc, use the folder of the module (unknown-module)",
+		"modules/subnet: depends on iam is not a module in the repository (unknown-module)",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got problems %q, want %q", problems, want)
+	}
+}
+
+func TestDot(t *testing.T) {
+	want := `digraph modules {
+  rankdir=LR;
+  node [shape=box];
+  "app";
+  "db";
+  "dns";
+  "subnet";
+  "vpc";
+  "nat" [style=dashed];
+  "app" -> "subnet";
+  "subnet" -> "vpc";
+  "app" -> "db" [style=dashed];
+  "app" -> "db" [style=dotted, dir=none];
+  "subnet" -> "nat" [style=dotted, dir=none];
+}
+`
+	if got := newModuleGraph(graphTestModules).dot(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResourceDot(t *testing.T) {
+	details := []CombinedModuleDetails{{Folder: "modules/app", TFDetails: parser.ModuleDetails{
+		Title:       "app",
+		Variables:   []parser.VariableDetails{{Name: "name"}},
+		Locals:      []parser.LocalDetails{{Name: "prefix"}},
+		Resources:   []parser.ResourceDetails{{Mode: "resource", Type: "aws_s3_bucket", Name: "this"}, {Mode: "data", Type: "aws_region", Name: "current"}},
+		ModuleCalls: []parser.ModuleCallDetails{{Name: "db", Source: "../db"}},
+		Outputs:     []parser.OutputDetails{{Name: "arn"}},
+		References: map[string][]string{
+			"output.arn":         {"aws_s3_bucket.this"},
+			"local.prefix":       {"var.name", "data.aws_region.current"},
+			"aws_s3_bucket.this": {"local.prefix"},
+		},
+	}}}
+	want := `digraph resources {
+  rankdir=LR;
+  node [shape=box];
+  subgraph cluster_0 {
+    label="app";
+    "app/var.name" [label="var.name", shape=ellipse];
+    "app/local.prefix" [label="local.prefix", shape=note];
+    "app/aws_s3_bucket.this" [label="aws_s3_bucket.this", shape=box];
+    "app/data.aws_region.current" [label="data.aws_region.current", shape=box, style=dashed];
+    "app/module.db" [label="module.db", shape=component];
+    "app/output.arn" [label="output.arn", shape=cds];
+    "app/aws_s3_bucket.this" -> "app/local.prefix";
+    "app/local.prefix" -> "app/var.name";
+    "app/local.prefix" -> "app/data.aws_region.current";
+    "app/output.arn" -> "app/aws_s3_bucket.this";
+  }
+}
+`
+	if got := resourceDot(details); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCreateGraph(t *testing.T) {
+	cases := []struct {
+		graphType string
+		modules   string
+		tag       string
+		nodes     []string
+	}{
+		{"modules", "", "", []string{`"app"`, `"db"`, `"dns"`, `"subnet"`, `"vpc"`, `"nat"`}},
+		// a filter keeps the matching modules and the modules joined to them
+		{"modules", "subnet", "", []string{`"app"`, `"subnet"`, `"vpc"`, `"nat"`}},
+		{"modules", "modules/vpc, dns", "", []string{`"dns"`, `"subnet"`, `"vpc"`}},
+		{"modules", "", "web", []string{`"app"`, `"db"`, `"subnet"`}},
+		{"modules", "subnet", "web", nil},
+		// the resources graph only has the matching modules
+		{"resources", "", "web", []string{`label="app"`}},
+		{"resources", "db,vpc", "", []string{`label="db"`, `label="vpc"`}},
+	}
+	for _, c := range cases {
+		got, err := createGraph(graphTestModules, c.graphType, c.modules, c.tag)
+		if c.nodes == nil {
+			if err == nil {
+				t.Errorf("%s %q %q: expected an error as no modules match", c.graphType, c.modules, c.tag)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		var nodes []string
+		for _, line := range strings.Split(got, "\n") {
+			line = strings.TrimSpace(line)
+			if c.graphType == "modules" && strings.HasPrefix(line, `"`) && !strings.Contains(line, "->") {
+				nodes = append(nodes, strings.Fields(strings.TrimSuffix(line, ";"))[0])
+			}
+			if c.graphType == "resources" && strings.HasPrefix(line, "label=") {
+				nodes = append(nodes, strings.TrimSuffix(line, ";"))
+			}
+		}
+		if !reflect.DeepEqual(nodes, c.nodes) {
+			t.Errorf("%s %q %q: got %q, want %q\n%s", c.graphType, c.modules, c.tag, nodes, c.nodes, got)
+		}
+	}
+	if _, err := createGraph(graphTestModules, "pie", "", ""); err == nil {
+		t.Errorf("expected an error for an unknown graph type")
+	}
+}
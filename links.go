@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// markdownLinkRe finds the url of each markdown link, urls made by writer.MakeLink never contain a )
+var markdownLinkRe = regexp.MustCompile(`\]\(([^)]*)\)`)
+
+// rewriteLinks changes the relative links in a markdown document which is moved from one file to another
+// targets maps the files the links point to, relative to the repository, to the files they are moved to
+// links to files which are not in targets are left as they are
+// if pretty is set links to index files point at their folder, as used by sites with pretty urls
+func rewriteLinks(text string, from string, to string, targets map[string]string, pretty bool) string {
+	return markdownLinkRe.ReplaceAllStringFunc(text, func(link string) string {
+		url := link[2 : len(link)-1]
+		if url == "" || strings.HasPrefix(url, "#") || strings.HasPrefix(url, "/") || strings.Contains(url, "://") {
+			return link
+		}
+		fragment := ""
+		if i := strings.Index(url, "#"); i >= 0 {
+			url, fragment = url[:i], url[i:]
+		}
+		target, ok := targets[path.Join(path.Dir(from), url)]
+		if !ok {
+			return link
+		}
+		url = relativePath(path.Dir(to), target)
+		if pretty {
+			switch path.Base(url) {
+			case "index.md", "_index.md":
+				url = path.Dir(url) + "/"
+			}
+		}
+		return "](" + url + fragment + ")"
+	})
+}
+
+// relativePath works out the path of a file relative to a folder, both given as slash separated paths
+func relativePath(folder string, file string) string {
+	from := strings.Split(path.Clean(folder), "/")
+	to := strings.Split(path.Clean(file), "/")
+	if from[0] == "." {
+		from = nil
+	}
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, to[common:]...)
+	return strings.Join(parts, "/")
+}
+
+// relativeFolder works out the relative path from one folder to another, with a trailing slash so it can be used as the base of links
+func relativeFolder(from string, to string) (string, error) {
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return "", err
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absFrom, absTo)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelativePath(t *testing.T) {
+	cases := []struct {
+		folder string
+		file   string
+		want   string
+	}{
+		{".", "index.md", "index.md"},
+		{".", "vpc/index.md", "vpc/index.md"},
+		{"vpc", "index.md", "../index.md"},
+		{"vpc", "vpc/variables.tf", "variables.tf"},
+		{"vpc", "subnet/index.md", "../subnet/index.md"},
+		{"aws/vpc", "aws/subnet/index.md", "../subnet/index.md"},
+		{"aws/vpc", "azure/vnet/index.md", "../../azure/vnet/index.md"},
+		{"vpc/", "vpc/examples/basic/main.tf", "examples/basic/main.tf"},
+	}
+	for _, c := range cases {
+		if got := relativePath(c.folder, c.file); got != c.want {
+			t.Errorf("relativePath(%q, %q) got %q, want %q", c.folder, c.file, got, c.want)
+		}
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	targets := map[string]string{
+		"README.md":                "index.md",
+		"modules/vpc/README.md":    "vpc/index.md",
+		"modules/subnet/README.md": "subnet/index.md",
+	}
+	cases := []struct {
+		text   string
+		pretty bool
+		want   string
+	}{
+		{"[subnet](../subnet/README.md)", false, "[subnet](../subnet/index.md)"},
+		{"[subnet](../subnet/README.md)", true, "[subnet](../subnet/)"},
+		{"[home](../../README.md#vpc)", false, "[home](../index.md#vpc)"},
+		{"[home](../../README.md)", true, "[home](../)"},
+		// links to files which are not moved, in the page and outside of the site are left alone
+		{"[cidr](variables.tf#L12)", false, "[cidr](variables.tf#L12)"},
+		{"[basic](examples/basic/main.tf)", false, "[basic](examples/basic/main.tf)"},
+		{"[inputs](#inputs)", false, "[inputs](#inputs)"},
+		{"[site](https://example.com/README.md)", false, "[site](https://example.com/README.md)"},
+		{"[root](/README.md)", false, "[root](/README.md)"},
+		{"[a](../subnet/README.md) and [b](../../README.md)", false, "[a](../subnet/index.md) and [b](../index.md)"},
+	}
+	for _, c := range cases {
+		if got := rewriteLinks(c.text, "modules/vpc/README.md", "vpc/index.md", targets, c.pretty); got != c.want {
+			t.Errorf("rewriteLinks(%q, pretty %v) got %q, want %q", c.text, c.pretty, got, c.want)
+		}
+	}
+}
+
+func TestRelativeFolder(t *testing.T) {
+	base := os.TempDir()
+	cases := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"out/modules/vpc", "repo/modules/vpc", "../../../repo/modules/vpc/"},
+		{"repo", "repo", "./"},
+		{"repo", "repo/modules", "modules/"},
+	}
+	for _, c := range cases {
+		got, err := relativeFolder(filepath.Join(base, c.from), filepath.Join(base, c.to))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if got != c.want {
+			t.Errorf("relativeFolder(%q, %q) got %q, want %q", c.from, c.to, got, c.want)
+		}
+	}
+}
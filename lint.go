@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+// severities a lint rule can be set to
+const (
+	severityOff   = "off"
+	severityWarn  = "warn"
+	severityError = "error"
+)
+
+// lintFinding is a problem found by a lint rule
+// file is relative to the module folder, line and endLine are the lines of the item and 0 for problems with the module as a whole
+type lintFinding struct {
+	rule     string
+	severity string
+	folder   string
+	file     string
+	line     int
+	endLine  int
+	message  string
+}
+
+// lintRule checks one of the documentation standards for a module
+// rules without a check are about how the modules refer to each other, and are checked across all of them by validateDependencies
+type lintRule struct {
+	name     string
+	desc     string
+	severity string
+	check    func(module CombinedModuleDetails) []lintFinding
+}
+
+// anyTypeRe matches types which use any
+var anyTypeRe = regexp.MustCompile(`\bany\b`)
+
+// sensitiveNameRe matches the names of variables which are likely to hold secrets
+var sensitiveNameRe = regexp.MustCompile(`(?i)(password|passwd|secret|token|private_key|api_key|access_key)`)
+
+// finding makes a finding for an item in a module
+func finding(module CombinedModuleDetails, loc parser.SourceLocation, message string) lintFinding {
+	// findings about the whole module, such as it having no header, are put at the start of its first file
+	if loc.File == "" && len(module.TFDetails.Files) > 0 {
+		loc = parser.SourceLocation{File: module.TFDetails.Files[0], StartLine: 1, EndLine: 1}
+	}
+	return lintFinding{folder: module.Folder, file: loc.File, line: loc.StartLine, endLine: loc.EndLine, message: message}
+}
+
+// endsInPunctuation checks if a description ends like a sentence
+func endsInPunctuation(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?")
+}
+
+// lintRules are the rules the lint command checks, with their default severities
+var lintRules = []lintRule{
+	{"missing-header", "the module has no header with a title and description", severityError, func(module CombinedModuleDetails) []lintFinding {
+		if module.TFDetails.Title != "" {
+			return nil
+		}
+		return []lintFinding{finding(module, parser.SourceLocation{}, "module has no header")}
+	}},
+	{"header-conflict", "the module has a header in more than one file, or its header has an unknown status", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, w := range module.TFDetails.Warnings {
+			r = append(r, finding(module, w.Location, w.Message))
+		}
+		return r
+	}},
+	{"title-folder", "the title of the module is not the name of its folder", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		m := module.TFDetails
+		if m.Title == "" || m.Title == filepath.Base(module.Folder) {
+			return nil
+		}
+		return []lintFinding{finding(module, m.HeaderLoc, fmt.Sprintf("title %s does not match the folder name %s", m.Title, filepath.Base(module.Folder)))}
+	}},
+	{"variable-description", "a variable has no description", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, v := range module.TFDetails.Variables {
+			if strings.TrimSpace(v.Desc) == "" {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("variable %s has no description", v.Name)))
+			}
+		}
+		return r
+	}},
+	{"variable-type", "a variable has no type", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, v := range module.TFDetails.Variables {
+			if v.DataType == "" {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("variable %s has no type", v.Name)))
+			}
+		}
+		return r
+	}},
+	{"any-type", "a variable has a type of any, or a type which contains any", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, v := range module.TFDetails.Variables {
+			if anyTypeRe.MatchString(v.DataType) {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("variable %s has type %s", v.Name, v.DataType)))
+			}
+		}
+		return r
+	}},
+	{"output-description", "an output has no description", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, o := range module.TFDetails.Outputs {
+			if strings.TrimSpace(o.Desc) == "" {
+				r = append(r, finding(module, o.Location, fmt.Sprintf("output %s has no description", o.Name)))
+			}
+		}
+		return r
+	}},
+	{"description-punctuation", "a description of the module, a variable or an output does not end in punctuation", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		m := module.TFDetails
+		if m.Desc != "" && !endsInPunctuation(m.Desc) {
+			r = append(r, finding(module, m.HeaderLoc, "module description does not end in punctuation"))
+		}
+		for _, v := range m.Variables {
+			if v.Desc != "" && !endsInPunctuation(v.Desc) {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("description of variable %s does not end in punctuation", v.Name)))
+			}
+		}
+		for _, o := range m.Outputs {
+			if o.Desc != "" && !endsInPunctuation(o.Desc) {
+				r = append(r, finding(module, o.Location, fmt.Sprintf("description of output %s does not end in punctuation", o.Name)))
+			}
+		}
+		return r
+	}},
+	{"unused-variable", "a variable is never used in the module", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		unused := module.TFDetails.UnusedVariables()
+		for _, v := range module.TFDetails.Variables {
+			if containsName(unused, v.Name) {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("variable %s is declared but never used", v.Name)))
+			}
+		}
+		return r
+	}},
+	{"empty-output", "an output does not refer to anything in the module", severityWarn, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		empty := module.TFDetails.EmptyOutputs()
+		for _, o := range module.TFDetails.Outputs {
+			if containsName(empty, o.Name) {
+				r = append(r, finding(module, o.Location, fmt.Sprintf("output %s does not refer to anything in the module", o.Name)))
+			}
+		}
+		return r
+	}},
+	{"sensitive-default", "a variable which is sensitive, or named like a secret, has a default value", severityError, func(module CombinedModuleDetails) []lintFinding {
+		var r []lintFinding
+		for _, v := range module.TFDetails.Variables {
+			if v.Def != "" && (v.Sensitive || sensitiveNameRe.MatchString(v.Name)) {
+				r = append(r, finding(module, v.Location, fmt.Sprintf("variable %s looks sensitive but has a default value", v.Name)))
+			}
+		}
+		return r
+	}},
+	{"unknown-module", "a module named in depends, partners or replaced-by is not in the repository, or could be more than one module", severityWarn, nil},
+	{"shared-name", "the module has the same folder name as another module", severityWarn, nil},
+	{"dependency-cycle", "modules depend on each other in a cycle", severityWarn, nil},
+}
+
+// lintSeverities works out the severity of each rule, from the defaults and the overrides given as rule=severity,...
+func lintSeverities(overrides string) (map[string]string, error) {
+	severities := make(map[string]string)
+	for _, rule := range lintRules {
+		severities[rule.name] = rule.severity
+	}
+	for _, o := range strings.Split(overrides, ",") {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			continue
+		}
+		parts := strings.SplitN(o, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if _, ok := severities[name]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("lint rule %s has no severity, expected %s=off, warn or error", name, name)
+		}
+		severity := strings.TrimSpace(parts[1])
+		if severity != severityOff && severity != severityWarn && severity != severityError {
+			return nil, fmt.Errorf("unknown severity %q for lint rule %s, expected off, warn or error", severity, name)
+		}
+		severities[name] = severity
+	}
+	return severities, nil
+}
+
+// suppressed checks if a comment in the module turns off a rule for a finding
+// a comment applies to the block which starts on the line after it, or the block it is written in
+func suppressed(module CombinedModuleDetails, f lintFinding) bool {
+	for _, s := range module.TFDetails.Suppressions {
+		if !containsName(s.Rules, f.rule) && !containsName(s.Rules, "all") {
+			continue
+		}
+		if s.Module {
+			return true
+		}
+		if s.File == f.file && f.line > 0 && s.Line >= f.line && s.Line <= f.endLine {
+			return true
+		}
+	}
+	return false
+}
+
+// lintModules runs the rules over the modules, returning what they found in order of module, file and line
+func lintModules(details []CombinedModuleDetails, severities map[string]string) []lintFinding {
+	var findings []lintFinding
+	modules := make(map[string]CombinedModuleDetails)
+	add := func(module CombinedModuleDetails, f lintFinding) {
+		f.severity = severities[f.rule]
+		if f.severity != severityOff && !suppressed(module, f) {
+			findings = append(findings, f)
+		}
+	}
+	for _, module := range details {
+		modules[module.Folder] = module
+		for _, rule := range lintRules {
+			if severities[rule.name] == severityOff || rule.check == nil {
+				continue
+			}
+			for _, f := range rule.check(module) {
+				f.rule = rule.name
+				add(module, f)
+			}
+		}
+	}
+	for _, f := range validateDependencies(details, newModuleGraph(details)) {
+		add(modules[f.folder], f)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.folder != b.folder {
+			return a.folder < b.folder
+		}
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		return a.line < b.line
+	})
+	return findings
+}
+
+// countErrors counts the findings from rules set to error
+func countErrors(findings []lintFinding) int {
+	errors := 0
+	for _, f := range findings {
+		if f.severity == severityError {
+			errors++
+		}
+	}
+	return errors
+}
+
+// path is where a finding is, relative to the repository
+func (f lintFinding) path() string {
+	if f.file == "" {
+		return f.folder
+	}
+	return f.folder + "/" + f.file
+}
+
+// printFindings writes each finding on a line
+func printFindings(w io.Writer, findings []lintFinding) {
+	for _, f := range findings {
+		where := f.path()
+		if f.line > 0 {
+			where = fmt.Sprintf("%s:%d", where, f.line)
+		}
+		fmt.Fprintf(w, "%s %s: %s (%s)\n", f.severity, where, f.message, f.rule)
+	}
+}
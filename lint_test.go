@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestLintSeverities(t *testing.T) {
+	severities, err := lintSeverities(" variable-type = error, any-type=off,,")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := map[string]string{"variable-type": severityError, "any-type": severityOff, "variable-description": severityWarn, "missing-header": severityError}
+	for rule, severity := range want {
+		if severities[rule] != severity {
+			t.Errorf("%s: got %q, want %q", rule, severities[rule], severity)
+		}
+	}
+	if len(severities) != len(lintRules) {
+		t.Errorf("got %d severities for %d rules", len(severities), len(lintRules))
+	}
+
+	bad := map[string]string{
+		"no-such-rule=warn":    `unknown lint rule "no-such-rule"`,
+		"variable-type":        "lint rule variable-type has no severity",
+		"variable-type=fatal":  `unknown severity "fatal" for lint rule variable-type`,
+		"variable-type=":       `unknown severity "" for lint rule variable-type`,
+		"any-type=off,typo=on": `unknown lint rule "typo"`,
+	}
+	for overrides, message := range bad {
+		if _, err := lintSeverities(overrides); err == nil || !strings.HasPrefix(err.Error(), message) {
+			t.Errorf("%q: got error %v, want %s", overrides, err, message)
+		}
+	}
+}
+
+// onlyRules are severities with every rule off except those given
+func onlyRules(rules map[string]string) map[string]string {
+	severities := make(map[string]string)
+	for _, rule := range lintRules {
+		severities[rule.name] = severityOff
+	}
+	for rule, severity := range rules {
+		severities[rule] = severity
+	}
+	return severities
+}
+
+func TestSuppressed(t *testing.T) {
+	variables := []parser.VariableDetails{
+		{Name: "a", Location: parser.SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 3}},
+		{Name: "b", Location: parser.SourceLocation{File: "variables.tf", StartLine: 5, EndLine: 7}},
+		{Name: "c", Location: parser.SourceLocation{File: "other.tf", StartLine: 1, EndLine: 3}},
+	}
+	cases := []struct {
+		name         string
+		suppressions []parser.Suppression
+		want         []string
+	}{
+		{"none", nil, []string{"other.tf:c", "variables.tf:a", "variables.tf:b"}},
+		{"ignore applies to its block", []parser.Suppression{{File: "variables.tf", Line: 5, Rules: []string{"variable-type"}}}, []string{"other.tf:c", "variables.tf:a"}},
+		{"ignore inside a block", []parser.Suppression{{File: "variables.tf", Line: 2, Rules: []string{"variable-type"}}}, []string{"other.tf:c", "variables.tf:b"}},
+		{"ignore in another file", []parser.Suppression{{File: "other.tf", Line: 5, Rules: []string{"variable-type"}}}, []string{"other.tf:c", "variables.tf:a", "variables.tf:b"}},
+		{"ignore another rule", []parser.Suppression{{File: "variables.tf", Line: 1, Rules: []string{"variable-description"}}}, []string{"other.tf:c", "variables.tf:a", "variables.tf:b"}},
+		{"ignore all", []parser.Suppression{{File: "variables.tf", Line: 1, Rules: []string{"all"}}}, []string{"other.tf:c", "variables.tf:b"}},
+		// ignore-module turns the rule off everywhere in the module, wherever the comment is
+		{"ignore-module", []parser.Suppression{{File: "main.tf", Line: 40, Module: true, Rules: []string{"variable-type"}}}, nil},
+		{"ignore-module another rule", []parser.Suppression{{File: "main.tf", Line: 40, Module: true, Rules: []string{"any-type"}}}, []string{"other.tf:c", "variables.tf:a", "variables.tf:b"}},
+	}
+	for _, c := range cases {
+		module := CombinedModuleDetails{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc", Variables: variables, Suppressions: c.suppressions}}
+		var got []string
+		for _, f := range lintModules([]CombinedModuleDetails{module}, onlyRules(map[string]string{"variable-type": severityWarn})) {
+			got = append(got, f.file+":"+strings.Fields(f.message)[1])
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLintStatus(t *testing.T) {
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc", Variables: []parser.VariableDetails{
+			{Name: "cidr", Desc: "The cidr.", Location: parser.SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 3}},
+		}}},
+	}
+	cases := []struct {
+		severity string
+		status   int
+		text     string
+	}{
+		{severityWarn, 0, "warn modules/vpc/variables.tf:1: variable cidr has no type (variable-type)\n"},
+		{severityError, 1, "error modules/vpc/variables.tf:1: variable cidr has no type (variable-type)\n"},
+		{severityOff, 0, ""},
+	}
+	for _, c := range cases {
+		severities := onlyRules(map[string]string{"variable-type": c.severity})
+		var b bytes.Buffer
+		status, err := reportFindings(&b, lintModules(modules, severities), severities, "text", "")
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if status != c.status || b.String() != c.text {
+			t.Errorf("%s: got status %d and %q, want %d and %q", c.severity, status, b.String(), c.status, c.text)
+		}
+	}
+	if status, err := reportFindings(&bytes.Buffer{}, nil, onlyRules(nil), "xml", ""); err == nil || status != 1 {
+		t.Errorf("an unknown format should fail, got status %d and %v", status, err)
+	}
+}
+
+func TestSensitiveDefault(t *testing.T) {
+	m, err := parser.New().ParseModule("parser/tests/null_default")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	modules := []CombinedModuleDetails{{Folder: "modules/db", TFDetails: m}}
+	var got []string
+	for _, f := range lintModules(modules, onlyRules(map[string]string{"sensitive-default": severityError})) {
+		got = append(got, f.message)
+	}
+	// a default of null is how a sensitive variable without a default is written, so only the real default is found
+	want := []string{"variable api_token looks sensitive but has a default value"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
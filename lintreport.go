@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// GitHub Actions workflow command escaping, see https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+var (
+	annotationDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// lintFormats are the formats the lint command can report findings in
+var lintFormats = []string{"text", "github", "sarif"}
+
+// reportFindings writes the findings in a format, the sarif log is written to the file out instead when it is given
+// returns the status the lint command exits with, which is 1 when a rule set to error found a problem
+func reportFindings(w io.Writer, findings []lintFinding, severities map[string]string, format string, out string) (int, error) {
+	switch format {
+	case "text":
+		printFindings(w, findings)
+	case "github":
+		printAnnotations(w, findings)
+	case "sarif":
+		log, err := sarifLog(findings, severities)
+		if err == nil && out != "" {
+			err = ioutil.WriteFile(out, log, 0644)
+		} else if err == nil {
+			_, err = w.Write(log)
+		}
+		if err != nil {
+			return 1, err
+		}
+	default:
+		return 1, fmt.Errorf("unknown lint format %q, expected one of %s", format, strings.Join(lintFormats, ", "))
+	}
+	errors := countErrors(findings)
+	fmt.Fprintf(progress, "%d problems found, %d errors\n", len(findings), errors)
+	if errors > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// printAnnotations writes each finding as a GitHub Actions workflow command, which annotates the lines in pull requests
+func printAnnotations(w io.Writer, findings []lintFinding) {
+	for _, f := range findings {
+		command := "warning"
+		if f.severity == severityError {
+			command = "error"
+		}
+		properties := []string{"file=" + annotationPropertyEscaper.Replace(f.path())}
+		if f.line > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", f.line), fmt.Sprintf("endLine=%d", f.endLine))
+		}
+		properties = append(properties, "title="+annotationPropertyEscaper.Replace(f.rule))
+		fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(properties, ","), annotationDataEscaper.Replace(f.message))
+	}
+}
+
+// sarif 2.1.0 log format, only the parts which are used, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// sarifLevels maps severities to sarif levels
+var sarifLevels = map[string]string{
+	severityOff:   "none",
+	severityWarn:  "warning",
+	severityError: "error",
+}
+
+// sarifLog builds a sarif log of the findings, for code scanning tools
+// locations are relative to the repository, which is the %SRCROOT% base
+func sarifLog(findings []lintFinding, severities map[string]string) ([]byte, error) {
+	driver := sarifDriver{
+		Name:           "tf-auto-document",
+		InformationURI: "https://github.com/richardjkendall/tf-auto-document",
+	}
+	index := make(map[string]int)
+	for i, rule := range lintRules {
+		index[rule.name] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.name,
+			ShortDescription:     sarifMessage{Text: rule.desc},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevels[severities[rule.name]]},
+		})
+	}
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, f := range findings {
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: f.path(), URIBaseID: "%SRCROOT%"},
+		}}
+		if f.line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: f.line, EndLine: f.endLine}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.rule,
+			RuleIndex: index[f.rule],
+			Level:     sarifLevels[f.severity],
+			Message:   sarifMessage{Text: f.message},
+			Locations: []sarifLocation{location},
+		})
+	}
+	data, err := json.MarshalIndent(sarifReport{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestAnnotationEscaping(t *testing.T) {
+	findings := []lintFinding{
+		{rule: "variable-type", severity: severityError, folder: "modules/a,b", file: "c:d.tf", line: 4, endLine: 8, message: "100% sure: a, b\r\nnext line"},
+		{rule: "missing-header", severity: severityWarn, folder: "modules/50%", message: "module has no header"},
+	}
+	want := "::error file=modules/a%2Cb/c%3Ad.tf,line=4,endLine=8,title=variable-type::100%25 sure: a, b%0D%0Anext line\n" +
+		"::warning file=modules/50%25,title=missing-header::module has no header\n"
+	var b bytes.Buffer
+	printAnnotations(&b, findings)
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestSarifLog(t *testing.T) {
+	severities, err := lintSeverities("variable-type=error,any-type=off")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	findings := []lintFinding{
+		{rule: "variable-type", severity: severityError, folder: "modules/vpc", file: "variables.tf", line: 4, endLine: 8, message: "variable cidr has no type"},
+		{rule: "missing-header", severity: severityError, folder: "modules/old", message: "module has no header"},
+	}
+	data, err := sarifLog(findings, severities)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID                   string `json:"id"`
+						ShortDescription     struct{ Text string }
+						DefaultConfiguration struct{ Level string }
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI       string `json:"uri"`
+							URIBaseID string `json:"uriBaseId"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine int `json:"startLine"`
+							EndLine   int `json:"endLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") || len(log.Runs) != 1 {
+		t.Fatalf("not a sarif 2.1.0 log with one run\n%s", data)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "tf-auto-document" || len(run.Tool.Driver.Rules) != len(lintRules) {
+		t.Errorf("driver should describe every rule\n%s", data)
+	}
+	levels := map[string]string{}
+	for i, rule := range run.Tool.Driver.Rules {
+		if rule.ID != lintRules[i].name || rule.ShortDescription.Text != lintRules[i].desc {
+			t.Errorf("rule %d is %s, want %s", i, rule.ID, lintRules[i].name)
+		}
+		levels[rule.ID] = rule.DefaultConfiguration.Level
+	}
+	if levels["variable-type"] != "error" || levels["any-type"] != "none" || levels["title-folder"] != "warning" {
+		t.Errorf("rule levels should follow the severities, got %v", levels)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(run.Results))
+	}
+	for i, r := range run.Results {
+		f := findings[i]
+		location := r.Locations[0].PhysicalLocation
+		if r.RuleID != f.rule || run.Tool.Driver.Rules[r.RuleIndex].ID != f.rule || r.Level != "error" || r.Message.Text != f.message {
+			t.Errorf("result %d does not match its finding %+v", i, r)
+		}
+		if location.ArtifactLocation.URI != f.path() || location.ArtifactLocation.URIBaseID != "%SRCROOT%" {
+			t.Errorf("result %d has location %+v", i, location.ArtifactLocation)
+		}
+	}
+	if region := run.Results[0].Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 4 || region.EndLine != 8 {
+		t.Errorf("result for a line should have a region, got %+v", region)
+	}
+	if region := run.Results[1].Locations[0].PhysicalLocation.Region; region != nil {
+		t.Errorf("result for a module should have no region, got %+v", region)
+	}
+
+	// code scanning needs a list of results, even when it is empty
+	empty, err := sarifLog(nil, severities)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if !strings.Contains(string(empty), `"results": []`) {
+		t.Errorf("empty log should have an empty list of results\n%s", empty)
+	}
+}
+
+func TestReportedFindings(t *testing.T) {
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{
+			Title:     "vpc",
+			Desc:      "A vpc.",
+			Depends:   []string{"iam"},
+			HeaderLoc: parser.SourceLocation{File: "main.tf", StartLine: 1, EndLine: 4},
+			Warnings: []parser.Warning{{
+				Message:  "module header also found in other.tf, using the one in main.tf",
+				Location: parser.SourceLocation{File: "other.tf", StartLine: 3, EndLine: 6},
+			}},
+		}},
+	}
+	severities := onlyRules(map[string]string{"header-conflict": severityWarn, "unknown-module": severityError})
+	findings := lintModules(modules, severities)
+	wants := map[string][]string{
+		"text": {
+			"error modules/vpc/main.tf:1: depends on iam is not a module in the repository (unknown-module)\n",
+			"warn modules/vpc/other.tf:3: module header also found in other.tf, using the one in main.tf (header-conflict)\n",
+		},
+		"github": {
+			"::error file=modules/vpc/main.tf,line=1,endLine=4,title=unknown-module::depends on iam is not a module in the repository\n",
+			"::warning file=modules/vpc/other.tf,line=3,endLine=6,title=header-conflict::module header also found in other.tf, using the one in main.tf\n",
+		},
+		"sarif": {`"ruleId": "unknown-module"`, `"uri": "modules/vpc/main.tf"`, `"ruleId": "header-conflict"`, `"startLine": 3`},
+	}
+	for format, want := range wants {
+		var b bytes.Buffer
+		status, err := reportFindings(&b, findings, severities, format, "")
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if status != 1 {
+			t.Errorf("%s: got status %d, the unknown module is an error", format, status)
+		}
+		for _, w := range want {
+			if !strings.Contains(b.String(), w) {
+				t.Errorf("%s: want %q in\n%s", format, w, b.String())
+			}
+		}
+	}
+}
+
+func TestModuleFindingsPointAtFirstFile(t *testing.T) {
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Files: []string{"main.tf", "variables.tf"}}},
+		{Folder: "modules/gcp/vpc", TFDetails: parser.ModuleDetails{
+			Title:     "vpc",
+			Desc:      "A vpc.",
+			HeaderLoc: parser.SourceLocation{File: "variables.tf", StartLine: 2, EndLine: 5},
+			Files:     []string{"main.tf", "variables.tf"},
+		}},
+	}
+	severities := onlyRules(map[string]string{"missing-header": severityError, "shared-name": severityWarn})
+	findings := lintModules(modules, severities)
+
+	// code scanning needs a file and region to show a finding, so findings about a whole module are put at the start of its first file
+	var b bytes.Buffer
+	if _, err := reportFindings(&b, findings, severities, "text", ""); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, want := range []string{
+		"error modules/aws/vpc/main.tf:1: module has no header (missing-header)\n",
+		"warn modules/aws/vpc/main.tf:1: modules modules/aws/vpc, modules/gcp/vpc are all called vpc",
+		"warn modules/gcp/vpc/variables.tf:2: modules modules/aws/vpc, modules/gcp/vpc are all called vpc",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q in\n%s", want, b.String())
+		}
+	}
+	data, err := sarifLog(findings, severities)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if strings.Contains(string(data), `"uri": "modules/aws/vpc",`) || !strings.Contains(string(data), `"uri": "modules/aws/vpc/main.tf"`) {
+		t.Errorf("module findings should point at a file\n%s", data)
+	}
+}
@@ -3,197 +3,659 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/richardjkendall/tf-auto-document/parser"
 	"github.com/richardjkendall/tf-auto-document/scangit"
 	"github.com/richardjkendall/tf-auto-document/writer"
 )
 
+// progress is where the progress messages are written, it is standard error when standard output holds
+// a graph, sarif log or coverage json so that they can be piped to another tool
+var progress io.Writer = os.Stdout
+
 // CombinedModuleDetails holds the combined module details
+// SourceURL is the base used for links to the module's source files, links are relative when it is empty
 type CombinedModuleDetails struct {
 	Folder     string
 	TFDetails  parser.ModuleDetails
 	GitDetails []scangit.GitCommit
+	SourceURL  string
+	// Configs are the root configurations in the repository which call the module
+	Configs []string
+	// Parent is the module this is a submodule of, from the parent's modules folder, and Submodules the modules in its own
+	Parent     *ModuleLink
+	Submodules []ModuleLink
 }
 
-func createModuleReadme(path string, details CombinedModuleDetails) error {
-	w := writer.New(path + "/README.md")
-	w.H1Underline(details.TFDetails.Title)
-	w.P(details.TFDetails.Desc)
-	if len(details.TFDetails.Depends) > 0 {
-		w.H2Underline("Depends on")
-		for _, d := range details.TFDetails.Depends {
-			w.Bullet(writer.MakeLink(d, "../"+d+"/README.md"))
-		}
-		w.P("")
+// ModuleLink is a link from a module to another module in the repository, Path is relative to the module's folder
+// and Folder is the other module's folder relative to the repository
+type ModuleLink struct {
+	Title  string
+	Desc   string
+	Path   string
+	Folder string
+}
+
+// linkSubmodules links each module in the modules folder of another module with that module
+// so that the submodules are listed in their parent's README and link back to it
+// modules are matched by their whole folder, as submodules of different modules often have the same name
+func linkSubmodules(modules []CombinedModuleDetails) {
+	index := make(map[string]int)
+	var folders []string
+	for i, m := range modules {
+		index[filepath.ToSlash(m.Folder)] = i
+		folders = append(folders, filepath.ToSlash(m.Folder))
 	}
-	if len(details.TFDetails.Partners) > 0 {
-		w.H2Underline("Works with")
-		for _, d := range details.TFDetails.Partners {
-			w.Bullet(writer.MakeLink(d, "../"+d+"/README.md"))
+	labels := moduleLabels(folders)
+	link := func(from CombinedModuleDetails, to CombinedModuleDetails) ModuleLink {
+		l := ModuleLink{Title: to.TFDetails.Title, Desc: to.TFDetails.Desc, Path: path.Base(to.Folder), Folder: filepath.ToSlash(to.Folder)}
+		if l.Title == "" {
+			l.Title = labels[filepath.ToSlash(to.Folder)]
+		}
+		if rel, err := filepath.Rel(filepath.FromSlash(from.Folder), filepath.FromSlash(to.Folder)); err == nil {
+			l.Path = filepath.ToSlash(rel)
 		}
-		w.P("")
+		return l
 	}
-	w.H2Underline("Releases")
-	var commitRows [][]string
-	for _, commit := range details.GitDetails {
-		if commit.Tag != "" {
-			row := []string{commit.Tag, strings.Trim(commit.Message, "\r\n"), writer.InlineCode(commit.Hash[0:7])}
-			commitRows = append(commitRows, row)
+	for i, m := range modules {
+		folder := filepath.ToSlash(m.Folder)
+		if path.Base(path.Dir(folder)) != "modules" {
+			continue
 		}
+		p, ok := index[path.Dir(path.Dir(folder))]
+		if !ok {
+			continue
+		}
+		parent := link(m, modules[p])
+		modules[i].Parent = &parent
+		modules[p].Submodules = append(modules[p].Submodules, link(modules[p], m))
 	}
-	fmt.Printf("Writing readme for %s with %d commits\n", path, len(commitRows))
-	if len(commitRows) > 0 {
-		commitHeaders := []string{"Tag", "Message", "Commit"}
-		w.Table(commitHeaders, commitRows)
-	} else {
-		w.P("There have been no releases yet for this module")
-	}
-	var varRows [][]string
-	for _, variable := range details.TFDetails.Variables {
-		dt := "`not specified`"
-		if variable.DataType != "" {
-			dt = writer.InlineCode(variable.DataType)
-		}
-		row := []string{writer.InlineCode(variable.Name), dt, variable.Desc, writer.InlineCode(variable.Def)}
-		varRows = append(varRows, row)
-	}
-	varHeaders := []string{"Name", "Type", "Description", "Default Value"}
-	w.H2Underline("Variables")
-	w.Table(varHeaders, varRows)
-	if len(details.TFDetails.Outputs) > 0 {
-		var outRows [][]string
-		for _, output := range details.TFDetails.Outputs {
-			row := []string{output.Name, output.Desc}
-			outRows = append(outRows, row)
-		}
-		outHeaders := []string{"Name", "Description"}
-		w.H2Underline("Outputs")
-		w.Table(outHeaders, outRows)
-	}
-	return w.WriteFile()
-}
-
-func createRootReadme(path string, details []CombinedModuleDetails) error {
-	w := writer.New(path + "/README.md")
-	w.H1Underline("Terraform Modules")
-	w.P("This is a collection of terraform modules")
-	w.P("Click on the links to see the details of each of the modules")
-	w.P("This documentation is auto-generated from the terraform files using tf-auto-document.")
-	var modRows [][]string
+}
+
+// statusColours maps module maturity levels to badge colours
+var statusColours = map[string]string{
+	parser.StatusExperimental: "orange",
+	parser.StatusBeta:         "yellow",
+	parser.StatusStable:       "brightgreen",
+	parser.StatusDeprecated:   "red",
+}
+
+// sourceLink makes a link to the lines of the file where an item was defined
+// baseURL is the base used for links to the module's source files, links are relative when it is empty
+func sourceLink(r writer.Renderer, baseURL string, loc parser.SourceLocation) string {
+	if loc.File == "" {
+		return ""
+	}
+	anchor := fmt.Sprintf("#L%d", loc.StartLine)
+	if loc.EndLine > loc.StartLine {
+		anchor = anchor + fmt.Sprintf("-L%d", loc.EndLine)
+	}
+	return r.Link(fmt.Sprintf("%s#L%d", loc.File, loc.StartLine), baseURL+loc.File+anchor)
+}
+
+// readmeOptions holds the settings which control what is included in the generated files
+type readmeOptions struct {
+	maintainer     bool
+	renderer       writer.Renderer
+	moduleTemplate *template.Template
+	rootTemplate   *template.Template
+	// rootTitle and rootIntro are the heading of the root README and the paragraphs under it, the defaults are used when empty
+	rootTitle string
+	rootIntro []string
+	// mermaidScript is the file or url of mermaid.js used to draw the graphs on the site, graphs are shown as their source when empty
+	mermaidScript string
+	// headersOnly is set when only the modules with a header get a page, as on the site and for site generators
+	headersOnly bool
+}
+
+// createModuleReadme builds the README for a module in memory
+func createModuleReadme(path string, details CombinedModuleDetails, graph moduleGraph, opts readmeOptions) (*writer.Writer, error) {
+	w := writer.NewWithRenderer(path+"/README"+opts.renderer.Extension(), opts.renderer)
+	data := newModuleTemplateData(details, graph, opts)
+	fmt.Fprintf(progress, "Creating readme for %s with %d commits\n", path, len(data.Releases))
+	text, err := executeTemplate(opts.moduleTemplate, data)
+	if err != nil {
+		return w, err
+	}
+	w.Write(text)
+	return w, nil
+}
+
+// createRootReadme builds the README for the root of the repository, which indexes the modules, in memory
+func createRootReadme(path string, details []CombinedModuleDetails, opts readmeOptions) (*writer.Writer, error) {
+	w := writer.NewWithRenderer(path+"/README"+opts.renderer.Extension(), opts.renderer)
 	for _, module := range details {
-		if module.TFDetails.Title != "" {
-			row := []string{module.TFDetails.Title, module.TFDetails.Desc, writer.MakeLink("more details", module.Folder+"/README.md")}
-			modRows = append(modRows, row)
-		} else {
-			fmt.Printf("error no details found for module in %s\n", module.Folder)
+		if module.TFDetails.Title == "" {
+			fmt.Fprintf(progress, "error no details found for module in %s\n", module.Folder)
 		}
 	}
-	headers := []string{"Module", "Description", "Link"}
-	w.H2Underline("Modules")
-	w.Table(headers, modRows)
-	return w.WriteFile()
+	text, err := executeTemplate(opts.rootTemplate, newRootTemplateData(details, opts))
+	if err != nil {
+		return w, err
+	}
+	w.Write(text)
+	return w, nil
 }
 
-func scanModulesFolder(path string, modulesfolder string, scanner *scangit.ScanGit) ([]CombinedModuleDetails, error) {
+// createReadmes builds the root README and the README for each module in memory
+func createReadmes(path string, details []CombinedModuleDetails, opts readmeOptions) ([]*writer.Writer, error) {
+	var files []*writer.Writer
+	root, err := createRootReadme(path, details, opts)
+	if err != nil {
+		return files, err
+	}
+	files = append(files, root)
+	graph := newModuleGraph(details)
+	for _, m := range details {
+		f, err := createModuleReadme(path+"/"+m.Folder, m, graph, opts)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// scanOptions holds the settings which control which modules are scanned and which of their tags are releases
+// include and exclude are globs matched against the module folders relative to the repository, e.g. modules/aws/**
+// tagPattern is a glob tags must match to be releases, with {module} standing for the name the module is shown with,
+// which is its folder name unless another module has the same one, and {folder} for its folder relative to the repository
+// skip are the folders the command writes to, which can hold copies of the modules, relative to the repository
+type scanOptions struct {
+	include    []string
+	exclude    []string
+	tagPattern string
+	skip       []string
+}
+
+// splitList splits a comma separated flag into its items, an empty flag has none
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// isRelease checks if a tag on a commit to a module is one of the module's releases, label is the name the module is shown with
+func (opts scanOptions) isRelease(tag string, folder string, label string) bool {
+	if tag == "" || opts.tagPattern == "" {
+		return tag != ""
+	}
+	pattern := strings.NewReplacer("{module}", label, "{folder}", folder).Replace(opts.tagPattern)
+	ok, _ := path.Match(pattern, tag)
+	return ok
+}
+
+// scanModules finds the modules in the repository under each of the roots, at any depth, and reads their details and commits
+func scanModules(path string, roots []string, scanner *scangit.ScanGit, opts scanOptions) ([]CombinedModuleDetails, error) {
 	var r []CombinedModuleDetails
-	files, err := ioutil.ReadDir(path + "/" + modulesfolder)
+	folders, err := findModuleFolders(path, roots, opts)
 	if err != nil {
 		return r, err
 	}
-	for _, file := range files {
-		// ignore . files
-		if strings.HasPrefix(file.Name(), ".") {
-			continue
+	labels := moduleLabels(folders)
+	for _, folder := range folders {
+		fmt.Fprintf(progress, "folder = %s\n", folder)
+		fullPath := filepath.Join(path, filepath.FromSlash(folder))
+		var cmd CombinedModuleDetails
+		cmd.Folder = folder
+		m, err := parser.New().ParseModule(fullPath)
+		if err != nil {
+			return r, err
 		}
-		fmt.Printf("folder = %s\n", modulesfolder+"/"+file.Name())
-		fullPath := filepath.Join(path+"/"+modulesfolder, file.Name())
-		// only look at directories
-		if file.IsDir() {
-			var cmd CombinedModuleDetails
-			cmd.Folder = modulesfolder + "/" + file.Name()
-			m, err := parser.New().ParseModule(fullPath)
-			if err != nil {
-				return r, err
+		for _, w := range m.Warnings {
+			fmt.Fprintf(progress, "warning %s:%d: %s\n", filepath.Join(fullPath, w.Location.File), w.Location.StartLine, w.Message)
+		}
+		for _, v := range m.UnusedVariables() {
+			fmt.Fprintf(progress, "warning %s: variable %s is declared but never used\n", fullPath, v)
+		}
+		for _, o := range m.EmptyOutputs() {
+			fmt.Fprintf(progress, "warning %s: output %s does not refer to anything in the module\n", fullPath, o)
+		}
+		cmd.TFDetails = m
+
+		// need to get commits
+		c, err := scanner.GetCommits(folder)
+		if err != nil {
+			return r, err
+		}
+		for i := range c {
+			if !opts.isRelease(c[i].Tag, folder, labels[folder]) {
+				c[i].Tag = ""
 			}
-			cmd.TFDetails = m
+		}
+		fmt.Fprintf(progress, "... got %d commits for this folder\n", len(c))
+		fmt.Fprintf(progress, "... commit data %+v\n", c)
+		cmd.GitDetails = c
+		r = append(r, cmd)
+	}
+	return r, nil
+}
 
-			// need to get commits
-			c, err := scanner.GetCommits(modulesfolder + "/" + file.Name())
+// scanConfigs finds the root configurations which call each of the modules from their module blocks
+// configs is a comma separated list of folders relative to the repository, which can include globs
+func scanConfigs(repo string, configs string, modules []CombinedModuleDetails) error {
+	for _, pattern := range splitList(configs) {
+		matches, err := filepath.Glob(filepath.Join(repo, strings.TrimSpace(pattern)))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(repo, match)
+			if err != nil {
+				return err
+			}
+			folder := filepath.ToSlash(rel)
+			fmt.Fprintf(progress, "config = %s\n", folder)
+			m, err := parser.New().ParseModule(match)
 			if err != nil {
-				return r, err
+				return err
+			}
+			for _, call := range m.ModuleCalls {
+				if !isLocalSource(call.Source) {
+					continue
+				}
+				// sources always use slashes, so the folders are compared as slash separated paths
+				target := path.Join(folder, call.Source)
+				for i := range modules {
+					if path.Clean(filepath.ToSlash(modules[i].Folder)) == target && !containsName(modules[i].Configs, folder) {
+						modules[i].Configs = append(modules[i].Configs, folder)
+					}
+				}
 			}
-			fmt.Printf("... got %d commits for this folder\n", len(c))
-			fmt.Printf("... commit data %+v\n", c)
-			cmd.GitDetails = c
-			r = append(r, cmd)
 		}
 	}
-	return r, nil
+	return nil
+}
+
+// linkToRepository makes the links to the source files of each module point from its README in the output folder back to the repository
+func linkToRepository(modules []CombinedModuleDetails, repo string, out string) error {
+	for i := range modules {
+		url, err := relativeFolder(filepath.Join(out, modules[i].Folder), filepath.Join(repo, modules[i].Folder))
+		if err != nil {
+			return err
+		}
+		modules[i].SourceURL = url
+	}
+	return nil
+}
+
+// commands which can be given before the flags, the first one is used if none is given
+var commands = []struct {
+	name string
+	desc string
+}{
+	{"generate", "write the README files"},
+	{"check", "check the README files are up to date without writing anything, exits with 1 if any are stale"},
+	{"export", "write the details of the modules as json or yaml for other tools to use"},
+	{"site", "write a static html site with a page for each module, navigation and search"},
+	{"docs", "write a page for each module and the navigation config for mkdocs, docusaurus or hugo"},
+	{"lint", "check the modules follow the documentation standards, exits with 1 if any rule set to error fails"},
+	{"stats", "report how much of the modules is documented, exits with 1 if the coverage is below -minimum"},
+	{"graph", "draw the graph of the modules, or of the resources in each module, in the graphviz dot language"},
+}
+
+// isCommand checks if an argument is the name of one of the commands
+func isCommand(arg string) bool {
+	for _, c := range commands {
+		if c.name == arg {
+			return true
+		}
+	}
+	return false
 }
 
 func main() {
 
+	// get command
+	command := commands[0].name
+	args := os.Args[1:]
+	if len(args) > 0 && isCommand(args[0]) {
+		command = args[0]
+		args = args[1:]
+	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+		for _, c := range commands {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n    \t%s\n", c.name, c.desc)
+		}
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nLint rules:\n")
+		for _, r := range lintRules {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s (%s)\n    \t%s\n", r.name, r.severity, r.desc)
+		}
+	}
+
 	// get args
 	tfRepoFolder := flag.String("repo", ".", "Path to the folder containing the Modules repository, defaults to current directory")
-	modulesSubFolder := flag.String("mods", "modules", "Sub-folder containing modules, defaults to 'modules'")
-	disableOutput := flag.Bool("outputoff", false, "Should the tool produce outputs")
+	modulesSubFolder := flag.String("mods", "modules", "Comma separated folders to look for modules in, relative to the repository, any folder under them with .tf files is a module, use . for the whole repository, defaults to 'modules'")
+	webURL := flag.String("weburl", "", "Web URL of the repository used to link to source files at the current commit, e.g. https://github.com/owner/repo, defaults to relative links")
+	maintainerNotes := flag.Bool("maintainer", false, "Should module READMEs include maintainer notes on unused variables and outputs, defaults to off")
+	outputFormat := flag.String("format", "markdown", "Format of the files to write, one of "+strings.Join(writer.Formats, ", ")+", defaults to markdown")
+	moduleTemplateFile := flag.String("moduletemplate", "", "Path to a Go text/template file used to write the module READMEs, defaults to the built-in layout")
+	rootTemplateFile := flag.String("roottemplate", "", "Path to a Go text/template file used to write the root README, defaults to the built-in layout")
+	outFolder := flag.String("out", "", "Folder to write the README files to instead of the repository, mirroring the module folders, defaults to the repository")
+	disableOutput := flag.Bool("outputoff", false, "Should the tool produce outputs, use -dryrun to see what would be written")
+	dryRunOutput := flag.Bool("dryrun", false, "Report which files would be created or changed, with a diff, without writing anything, defaults to off")
+	exportFormat := flag.String("exportformat", "json", "Format used by the export command, one of json, yaml, defaults to json")
+	exportMode := flag.String("exportmode", "both", "Files written by the export command, one of module (a file in each module folder), catalog (one file for all modules) or both, defaults to both")
+	siteFolder := flag.String("sitedir", "site", "Folder the site command writes the html site to, defaults to 'site'")
+	mermaidScript := flag.String("mermaidjs", "", "File, which is copied into the site, or url of mermaid.min.js used to draw the graphs on the pages of the site, defaults to none, which shows the source of the graphs")
+	docsFormat := flag.String("docsformat", "mkdocs", "Site generator the docs command writes pages for, one of "+strings.Join(docsFormats, ", ")+", defaults to mkdocs")
+	docsFolder := flag.String("docsdir", "docs/modules", "Folder the docs command writes the pages to, inside the docs folder of the site, defaults to 'docs/modules'")
+	graphType := flag.String("graphtype", "modules", "Graph drawn by the graph command, one of "+strings.Join(graphTypes, ", ")+", defaults to modules")
+	graphModules := flag.String("graphmodules", "", "Comma separated names or folders of the modules to include in the graph, defaults to all modules")
+	graphTag := flag.String("graphtag", "", "Tag a module must have to be included in the graph, defaults to any tag")
+	graphOut := flag.String("graphout", "", "File the graph command writes the graph to, defaults to standard output")
+	rootConfigs := flag.String("configs", "", "Comma separated folders of root configurations, relative to the repository and which can include globs, to list in the Used by section of the modules they call, defaults to none")
+	strictDepends := flag.Bool("strict", false, "Treat modules missing from depends, partners or replaced-by, and dependency cycles, as errors rather than warnings, defaults to off")
+	lintRuleSeverities := flag.String("lintrules", "", "Comma separated severities for the lint rules, e.g. variable-type=error,any-type=off, defaults to each rule's own severity")
+	lintFormat := flag.String("lintformat", "text", "Format the lint command reports problems in, one of text, github (workflow commands which annotate pull requests) or sarif, defaults to text")
+	lintOut := flag.String("lintout", "", "File the lint command writes the sarif log to, defaults to standard output")
+	statsFormat := flag.String("statsformat", "table", "Format the stats command reports coverage in, one of table, json or badge (a shields.io endpoint badge), defaults to table")
+	statsOut := flag.String("statsout", "", "File the stats command writes the coverage to, defaults to standard output")
+	minimumCoverage := flag.Float64("minimum", 0, "Percentage of overall documentation coverage below which the stats command fails, defaults to 0")
+	includeModules := flag.String("include", "", "Comma separated globs of the module folders to document, relative to the repository, e.g. modules/aws-*, defaults to all")
+	excludeModules := flag.String("exclude", "", "Comma separated globs of the module folders not to document, relative to the repository, defaults to none")
+	tagPattern := flag.String("tagpattern", "", "Glob the tags of a module's releases must match, {module} stands for the module folder name, or its folder when another module has the same name, and {folder} for its folder, e.g. {module}/v*, defaults to any tag")
+	moduleSectionList := flag.String("sections", "", "Comma separated sections of the built-in module README layout to include, in order, one of "+strings.Join(sectionNames(), ", ")+", defaults to all in that order")
+	configFile := flag.String("config", "", "Path to the config file, defaults to "+configFileName+" in the repository if there is one")
 	debugLogs := flag.Bool("debug", false, "Should we display debug logs, defaults to off")
-	flag.Parse()
+	flag.CommandLine.Parse(args)
+
+	// settings in the config file are used for the flags which are not given
+	config, configPath, err := loadConfig(*configFile, *tfRepoFolder)
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+	if configPath != "" {
+		err = applyConfig(flag.CommandLine, config, filepath.Dir(configPath))
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+	}
+
+	if (command == "graph" && *graphOut == "") || (command == "lint" && *lintFormat == "sarif" && *lintOut == "") ||
+		(command == "stats" && *statsFormat != "table" && *statsOut == "") {
+		progress = os.Stderr
+	}
 	folderToScan := *tfRepoFolder
 
-	fmt.Printf("Working on repository: %s\n", folderToScan)
-	fmt.Printf("Modules sub-folder: %s\n", *modulesSubFolder)
+	fmt.Fprintf(progress, "Working on repository: %s\n", folderToScan)
+	if configPath != "" {
+		fmt.Fprintf(progress, "Config file: %s\n", configPath)
+	}
+	fmt.Fprintf(progress, "Modules folders: %s\n", *modulesSubFolder)
 
 	// create gitscanner for this repo
-	fmt.Printf("Scanning git repository...\n")
+	fmt.Fprintf(progress, "Scanning git repository...\n")
 	scanner := scangit.New()
-	err := scanner.Open(folderToScan)
+	err = scanner.Open(folderToScan)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(progress, err)
 		os.Exit(1)
 	}
 	// load tags
 	err = scanner.LoadTags()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(progress, err)
 		os.Exit(1)
 	}
-	fmt.Printf("... scan complete.  Got %d tags\n", len(scanner.GetTags()))
+	fmt.Fprintf(progress, "... scan complete.  Got %d tags\n", len(scanner.GetTags()))
 	if *debugLogs {
-		fmt.Printf("Tags: %+v\n", scanner.GetTags())
+		fmt.Fprintf(progress, "Tags: %+v\n", scanner.GetTags())
 	}
 
 	// scan terraform files
-	fmt.Printf("Scanning terrform modules...\n")
-	mod, err := scanModulesFolder(folderToScan, *modulesSubFolder, scanner)
+	fmt.Fprintf(progress, "Scanning terrform modules...\n")
+	// the folder the command writes to can hold copies of the modules, from -out or the sources copied by the site and docs commands
+	outputs := map[string]string{"generate": *outFolder, "check": *outFolder, "site": *siteFolder, "docs": *docsFolder}
+	skip, err := skipFolders(folderToScan, outputs[command])
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+	mod, err := scanModules(folderToScan, splitList(*modulesSubFolder), scanner, scanOptions{
+		include:    splitList(*includeModules),
+		exclude:    splitList(*excludeModules),
+		tagPattern: *tagPattern,
+		skip:       skip,
+	})
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(progress, err)
 		os.Exit(1)
 	}
-	fmt.Printf("... scan complete.  Got %d modules\n", len(mod))
+	fmt.Fprintf(progress, "... scan complete.  Got %d modules\n", len(mod))
+	linkSubmodules(mod)
+	if *rootConfigs != "" {
+		fmt.Fprintf(progress, "Scanning root configurations...\n")
+		err = scanConfigs(folderToScan, *rootConfigs, mod)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+	}
 
-	if !*disableOutput {
-		// create root md file
-		rerr := createRootReadme(folderToScan, mod)
-		if rerr != nil {
-			fmt.Println(rerr)
+	// check the modules refer to each other correctly, the lint command reports these with the other findings
+	if command != "lint" {
+		problems := validateDependencies(mod, newModuleGraph(mod))
+		for i := range problems {
+			problems[i].severity = severityWarn
+			if *strictDepends {
+				problems[i].severity = severityError
+			}
+		}
+		printFindings(progress, problems)
+		if *strictDepends && len(problems) > 0 {
+			os.Exit(1)
+		}
+	}
+
+	// work out where links to the source files should point
+	if *webURL != "" {
+		commit, err := scanner.HeadCommit()
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		base := strings.TrimRight(*webURL, "/") + "/blob/" + commit
+		if strings.Contains(*webURL, "{commit}") {
+			base = strings.TrimRight(strings.Replace(*webURL, "{commit}", commit, -1), "/")
+		}
+		for i := range mod {
+			mod[i].SourceURL = base + "/" + mod[i].Folder + "/"
+		}
+	} else if *outFolder != "" {
+		err = linkToRepository(mod, folderToScan, *outFolder)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+	}
+
+	if command == "lint" {
+		severities, err := lintSeverities(*lintRuleSeverities)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		findings := lintModules(mod, severities)
+		status, err := reportFindings(os.Stdout, findings, severities, *lintFormat, *lintOut)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		if status != 0 {
+			os.Exit(status)
+		}
+		return
+	}
+
+	if command == "stats" {
+		stats := newCoverageStats(mod)
+		var w io.Writer = os.Stdout
+		if *statsOut != "" {
+			f, err := os.Create(*statsOut)
+			if err != nil {
+				fmt.Fprintln(progress, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := writeStats(w, stats, *statsFormat); err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		if err := stats.checkMinimum(*minimumCoverage); err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == "graph" {
+		graph, err := createGraph(mod, *graphType, *graphModules, *graphTag)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		if *graphOut != "" {
+			err = ioutil.WriteFile(*graphOut, []byte(graph), 0644)
+		} else {
+			_, err = os.Stdout.WriteString(graph)
+		}
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == "export" {
+		err := exportModules(folderToScan, mod, *exportFormat, *exportMode)
+		if err != nil {
+			fmt.Fprintln(progress, err)
 			os.Exit(1)
 		}
+		return
+	}
+
+	opts := readmeOptions{
+		maintainer: *maintainerNotes,
+		rootTitle:  config.Root.Title,
+		rootIntro:  config.Root.Intro,
+
+		mermaidScript: *mermaidScript,
+	}
+	// the pages of the site are always html, and the pages for site generators are always markdown
+	if command == "site" {
+		*outputFormat = "html"
+	}
+	if command == "docs" {
+		*outputFormat = "markdown"
+	}
+	opts.renderer, err = writer.NewRenderer(*outputFormat)
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+	moduleLayout, err := defaultModuleTemplate(splitList(*moduleSectionList))
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+	opts.moduleTemplate, err = loadTemplate("module", *moduleTemplateFile, moduleLayout, opts.renderer)
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+	opts.rootTemplate, err = loadTemplate("root", *rootTemplateFile, defaultRootTemplate, opts.renderer)
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
 
-		// create each module's md file
-		for _, m := range mod {
-			merr := createModuleReadme(folderToScan+"/"+m.Folder, m)
-			if merr != nil {
-				fmt.Println(rerr)
+	if command == "site" {
+		err := createSite(folderToScan, *siteFolder, mod, opts)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == "docs" {
+		err := createDocs(folderToScan, *docsFolder, *docsFormat, mod, opts)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// the README files are written to the repository unless another folder is given
+	docsRoot := folderToScan
+	if *outFolder != "" {
+		docsRoot = *outFolder
+		fmt.Fprintf(progress, "Output folder: %s\n", docsRoot)
+	}
+	files, err := createReadmes(docsRoot, mod, opts)
+	if err != nil {
+		fmt.Fprintln(progress, err)
+		os.Exit(1)
+	}
+
+	if command == "check" {
+		stale, err := checkReadmes(os.Stdout, docsRoot, files)
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		if stale > 0 {
+			fmt.Fprintf(progress, "%d README files are out of date, run tf-auto-document to regenerate them\n", stale)
+			os.Exit(1)
+		}
+		fmt.Fprintf(progress, "All README files are up to date\n")
+		return
+	}
+
+	if *dryRunOutput {
+		err := dryRun(os.Stdout, docsRoot, files, useColour())
+		if err != nil {
+			fmt.Fprintln(progress, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !*disableOutput {
+		// write the root and each module's md file
+		for _, f := range files {
+			werr := f.WriteFile()
+			if werr != nil {
+				fmt.Fprintln(progress, werr)
 				os.Exit(1)
 			}
 		}
 	} else {
-		fmt.Printf("Output is disabled\n")
+		fmt.Fprintf(progress, "Output is disabled\n")
 	}
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestIsRelease(t *testing.T) {
+	cases := []struct {
+		pattern string
+		tag     string
+		folder  string
+		label   string
+		want    bool
+	}{
+		{"", "v1.0.0", "modules/vpc", "vpc", true},
+		{"", "", "modules/vpc", "vpc", false},
+		{"{module}/v*", "vpc/v1.0.0", "modules/vpc", "vpc", true},
+		{"{module}/v*", "subnet/v1.0.0", "modules/vpc", "vpc", false},
+		{"{module}/v*", "v1.0.0", "modules/vpc", "vpc", false},
+		// when two modules have the same folder name, {module} is the folder so that a tag is only a release of one of them
+		{"{module}/v*", "vpc/v1.0.0", "modules/aws/vpc", "modules/aws/vpc", false},
+		{"{module}/v*", "modules/aws/vpc/v1.0.0", "modules/aws/vpc", "modules/aws/vpc", true},
+		{"{module}/v*", "modules/aws/vpc/v1.0.0", "modules/gcp/vpc", "modules/gcp/vpc", false},
+		{"{folder}@*", "modules/vpc@1.2.0", "modules/vpc", "vpc", true},
+		{"{folder}@*", "vpc@1.2.0", "modules/vpc", "vpc", false},
+	}
+	for _, c := range cases {
+		opts := scanOptions{tagPattern: c.pattern}
+		if got := opts.isRelease(c.tag, c.folder, c.label); got != c.want {
+			t.Errorf("isRelease(%q, %q, %q) with %q got %v, want %v", c.tag, c.folder, c.label, c.pattern, got, c.want)
+		}
+	}
+}
+
+func TestLinkSubmodules(t *testing.T) {
+	modules := []CombinedModuleDetails{
+		{Folder: "a", TFDetails: parser.ModuleDetails{Title: "a", Desc: "Module a."}},
+		{Folder: "a/modules/iam", TFDetails: parser.ModuleDetails{Title: "a-iam", Desc: "Roles for a."}},
+		{Folder: "a/modules/network"},
+		{Folder: "b", TFDetails: parser.ModuleDetails{Title: "b"}},
+		{Folder: "b/modules/iam", TFDetails: parser.ModuleDetails{Title: "b-iam"}},
+		{Folder: "b/modules/iam/modules/policy", TFDetails: parser.ModuleDetails{Title: "policy"}},
+		{Folder: "c/modules/iam", TFDetails: parser.ModuleDetails{Title: "c-iam"}},
+		{Folder: "d/extra/iam", TFDetails: parser.ModuleDetails{Title: "d-iam"}},
+	}
+	linkSubmodules(modules)
+	parents := map[string]*ModuleLink{
+		"a/modules/iam":                {Title: "a", Desc: "Module a.", Path: "../..", Folder: "a"},
+		"a/modules/network":            {Title: "a", Desc: "Module a.", Path: "../..", Folder: "a"},
+		"b/modules/iam":                {Title: "b", Path: "../..", Folder: "b"},
+		"b/modules/iam/modules/policy": {Title: "b-iam", Path: "../..", Folder: "b/modules/iam"},
+	}
+	submodules := map[string][]ModuleLink{
+		"a": {{Title: "a-iam", Desc: "Roles for a.", Path: "modules/iam", Folder: "a/modules/iam"}, {Title: "network", Path: "modules/network", Folder: "a/modules/network"}},
+		"b": {{Title: "b-iam", Path: "modules/iam", Folder: "b/modules/iam"}},
+		// a submodule of a submodule is only listed by its own parent
+		"b/modules/iam": {{Title: "policy", Path: "modules/policy", Folder: "b/modules/iam/modules/policy"}},
+	}
+	for _, m := range modules {
+		if !reflect.DeepEqual(m.Parent, parents[m.Folder]) {
+			t.Errorf("%s: got parent %+v, want %+v", m.Folder, m.Parent, parents[m.Folder])
+		}
+		if !reflect.DeepEqual(m.Submodules, submodules[m.Folder]) {
+			t.Errorf("%s: got submodules %+v, want %+v", m.Folder, m.Submodules, submodules[m.Folder])
+		}
+	}
+}
+
+func TestScanConfigs(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	files := map[string]string{
+		"live/prod/main.tf": "module \"vpc\" {\n  source = \"../../modules/vpc\"\n}\n",
+		"live/dev/main.tf":  "module \"vpc\" {\n  source = \"../../modules/vpc\"\n}\n\nmodule \"other\" {\n  source = \"hashicorp/consul/aws\"\n}\n",
+		"live/notes.txt":    "not a configuration\n",
+	}
+	for file, text := range files {
+		if err := writeOutputFile(filepath.Join(repo, filepath.FromSlash(file)), []byte(text)); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc"}}},
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+	}
+	if err := scanConfigs(repo, "live/*", modules); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if want := []string{"live/dev", "live/prod"}; !reflect.DeepEqual(modules[1].Configs, want) {
+		t.Errorf("got configs %q for vpc, want %q", modules[1].Configs, want)
+	}
+	if len(modules[0].Configs) != 0 {
+		t.Errorf("got configs %q for subnet which is not called", modules[0].Configs)
+	}
+
+	// the configurations are listed after the modules in the Used by section
+	opts := testOptions(t, "markdown")
+	text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(modules[1], newModuleGraph(modules), opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := "Used by\n------\n\n* [subnet](../subnet/README.md)\n* `live/dev`\n* `live/prod`\n"
+	if !strings.Contains(text, want) {
+		t.Errorf("want %q in\n%s", want, text)
+	}
+}
+
+func TestScanConfigsSourcePaths(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	// sources are slash separated and need not be clean
+	text := "module \"vpc\" {\n  source = \"./../../../modules/aws/vpc/\"\n}\n"
+	if err := writeOutputFile(filepath.Join(repo, "envs", "prod", "eu", "main.tf"), []byte(text)); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	modules := []CombinedModuleDetails{{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}}}
+	// no configurations are scanned when none are given
+	if err := scanConfigs(repo, "", modules); err != nil || len(modules[0].Configs) != 0 {
+		t.Fatalf("got configs %q and %v with no configurations given", modules[0].Configs, err)
+	}
+	if err := scanConfigs(repo, "envs/*/*", modules); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if want := []string{"envs/prod/eu"}; !reflect.DeepEqual(modules[0].Configs, want) {
+		t.Errorf("got configs %q for vpc, want %q", modules[0].Configs, want)
+	}
+}
+
+func TestReadmesInOutputFolder(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "out")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(tmp)
+	repo := filepath.Join(tmp, "repo")
+	out := filepath.Join(tmp, "docs")
+	if err := writeOutputFile(filepath.Join(repo, "modules", "vpc", "variables.tf"), []byte("variable \"cidr\" {}\n")); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{
+			Title:     "vpc",
+			Variables: []parser.VariableDetails{{Name: "cidr", Location: parser.SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 1}}},
+		}},
+	}
+	if err := linkToRepository(modules, repo, out); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if want := "../../../repo/modules/vpc/"; modules[0].SourceURL != want {
+		t.Errorf("got source url %q, want %q", modules[0].SourceURL, want)
+	}
+	files, err := createReadmes(out, modules, testOptions(t, "markdown"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+
+	// before anything is written the check and dry run compare with the output folder, where the files are missing
+	var b bytes.Buffer
+	if err := dryRun(&b, out, files, false); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, text := range []string{"README.md would be created\n", "modules/vpc/README.md would be created\n"} {
+		if !strings.Contains(b.String(), text) {
+			t.Errorf("expected %q in\n%s", text, b.String())
+		}
+	}
+	for _, f := range files {
+		if err := f.WriteFile(); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	if stale, err := checkReadmes(&b, out, files); err != nil || stale != 0 {
+		t.Errorf("got %d stale files and %v after writing them", stale, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(out, "README.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if stale, err := checkReadmes(&b, out, files); err != nil || stale != 1 {
+		t.Errorf("got %d stale files and %v after editing the root README in the output folder", stale, err)
+	}
+
+	// the READMEs mirror the module folders in the output folder, and nothing is written to the repository
+	page, err := ioutil.ReadFile(filepath.Join(out, "modules", "vpc", "README.md"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "README.md")); err != nil {
+		t.Errorf("root README should be in the output folder: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "modules", "vpc", "README.md")); !os.IsNotExist(err) {
+		t.Errorf("no README should be written to the repository")
+	}
+	// the link to where the variable is defined points back to the source in the repository
+	link := "(../../../repo/modules/vpc/variables.tf#L1)"
+	if !strings.Contains(string(page), link) {
+		t.Errorf("want %q in\n%s", link, page)
+	}
+	if _, err := os.Stat(filepath.Join(out, "modules", "vpc", "../../../repo/modules/vpc/variables.tf")); err != nil {
+		t.Errorf("the source link should lead to the file: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// suppressionRe matches the comments which turn off lint rules, e.g. # tf-auto-document:ignore variable-type
+var suppressionRe = regexp.MustCompile(`^tf-auto-document:(ignore|ignore-module)\s+(\S.*)$`)
+
+// Suppression records the lint rules turned off by a comment
+// Line is the line of code the comment applies to, the comment is written on or before it
+// if Module is set the rules are turned off for the whole module instead and Line is the line of the comment
+type Suppression struct {
+	File   string
+	Line   int
+	Module bool
+	Rules  []string
+}
+
+// docComments finds the runs of comments in a file which immediately precede a line of code
+// returns a map of the line the code starts on to the text of the comments, with the comment markers removed
+// and the lint rules turned off by comments, which are not included in the text
+func docComments(src []byte, filename string) (map[int]string, []Suppression) {
+	docs := make(map[int]string)
+	var suppressions []Suppression
+	var pending []Suppression
+	tokens, _ := hclsyntax.LexConfig(src, filename, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	var run []string
+	nextLine := 0
+	codeLine := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenNewline:
+			continue
+		case hclsyntax.TokenComment:
+			text := string(token.Bytes)
+			if match := suppressionRe.FindStringSubmatch(cleanComment(text)); match != nil {
+				s := Suppression{
+					File:   filepath.Base(filename),
+					Line:   codeLine,
+					Module: match[1] == "ignore-module",
+					Rules:  splitList(match[2]),
+				}
+				// comments on their own line apply to the next line of code
+				if s.Module {
+					s.Line = token.Range.Start.Line
+				}
+				if s.Module || token.Range.Start.Line == codeLine {
+					suppressions = append(suppressions, s)
+				} else {
+					pending = append(pending, s)
+				}
+				if token.Range.Start.Line != codeLine {
+					nextLine = token.Range.End.Line
+					if !strings.HasSuffix(text, "\n") {
+						nextLine++
+					}
+				}
+				continue
+			}
+			// comments which trail code or are the module header are not documentation
+			if token.Range.Start.Line == codeLine || headerStartRe.MatchString(text) {
+				run = nil
+				continue
+			}
+			if token.Range.Start.Line != nextLine {
+				run = nil
+			}
+			run = append(run, cleanComment(text))
+			// line comments include their newline, block comments do not
+			nextLine = token.Range.End.Line
+			if !strings.HasSuffix(text, "\n") {
+				nextLine++
+			}
+		default:
+			if len(run) > 0 && token.Range.Start.Line == nextLine {
+				docs[token.Range.Start.Line] = strings.Trim(strings.Join(run, "\n"), "\n")
+			}
+			for _, s := range pending {
+				s.Line = token.Range.Start.Line
+				suppressions = append(suppressions, s)
+			}
+			pending = nil
+			run = nil
+			codeLine = token.Range.End.Line
+		}
+	}
+	return docs, suppressions
+}
+
+// cleanComment removes the comment markers from a comment
+func cleanComment(text string) string {
+	text = strings.TrimRight(text, " \r\n")
+	if strings.HasPrefix(text, "/*") {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			line = strings.TrimRight(line, " \r")
+			// strip the leading * used to line up the body of block comments
+			trimmed := strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(trimmed, "*") {
+				line = strings.TrimPrefix(strings.TrimPrefix(trimmed, "*"), " ")
+			}
+			lines[i] = line
+		}
+		return strings.Trim(strings.Join(lines, "\n"), " \n")
+	}
+	if strings.HasPrefix(text, "#") {
+		text = strings.TrimPrefix(text, "#")
+	} else {
+		text = strings.TrimPrefix(text, "//")
+	}
+	return strings.TrimPrefix(text, " ")
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// examplesFolder is the folder in a module which holds examples of how to use it, one in each sub-folder
+const examplesFolder = "examples"
+
+// ExampleDetails contains the details of an example of how to use the module, from a folder in its examples folder
+// Folder is relative to the module folder, Files are the names of the files in it and Main is the contents of its main.tf
+type ExampleDetails struct {
+	Name   string
+	Folder string
+	Files  []string
+	Main   string
+}
+
+// findExamples reads the examples in the examples folder of a module, in name order
+// it is not an error for the module to have no examples folder
+func findExamples(path string) ([]ExampleDetails, error) {
+	var r []ExampleDetails
+	folders, err := ioutil.ReadDir(filepath.Join(path, examplesFolder))
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	for _, folder := range folders {
+		// ignore . files and anything which is not a folder
+		if strings.HasPrefix(folder.Name(), ".") || !folder.IsDir() {
+			continue
+		}
+		example := ExampleDetails{
+			Name:   folder.Name(),
+			Folder: examplesFolder + "/" + folder.Name(),
+		}
+		fullPath := filepath.Join(path, examplesFolder, folder.Name())
+		files, err := ioutil.ReadDir(fullPath)
+		if err != nil {
+			return r, err
+		}
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), ".") || file.IsDir() {
+				continue
+			}
+			example.Files = append(example.Files, file.Name())
+			if file.Name() == "main.tf" {
+				data, err := ioutil.ReadFile(filepath.Join(fullPath, file.Name()))
+				if err != nil {
+					return r, err
+				}
+				example.Main = strings.Replace(string(data), "\r\n", "\n", -1)
+			}
+		}
+		r = append(r, example)
+	}
+	return r, nil
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// names of the sidecar files which can hold the module header instead of a comment in a tf file
+const (
+	headerYamlFile     = "module.yaml"
+	headerMarkdownFile = "README.header.md"
+)
+
+var (
+	headerStartRe = regexp.MustCompile(`(?m)^\/\*\r?\n(title:)`)
+	headerEndRe   = regexp.MustCompile(`(?m)^\*\/`)
+	frontMatterRe = regexp.MustCompile(`(?s)^---\r?\n(.*?)\r?\n---\r?\n?`)
+	titleRe       = regexp.MustCompile(`^[\w\-]+$`)
+)
+
+// moduleHeader is the layout of the sidecar module.yaml file and of the front matter in README.header.md
+type moduleHeader struct {
+	Title      string   `yaml:"title"`
+	Desc       string   `yaml:"desc"`
+	Partners   []string `yaml:"partners"`
+	Depends    []string `yaml:"depends"`
+	Owners     []string `yaml:"owners"`
+	Team       string   `yaml:"team"`
+	Status     string   `yaml:"status"`
+	Tags       []string `yaml:"tags"`
+	Deprecated string   `yaml:"deprecated"`
+	ReplacedBy string   `yaml:"replaced-by"`
+	loc        SourceLocation
+}
+
+// findHeader looks for the module header, first in the sidecar files and then in each of the tf files
+// main.tf is checked before the other tf files, the first header found wins and any others are reported as warnings
+func (parser *Parser) findHeader(path string, tfFiles []string) (ModuleDetails, []Warning, error) {
+	var r ModuleDetails
+	var warnings []Warning
+	var source string
+	var candidates []string
+	for _, name := range []string{headerYamlFile, headerMarkdownFile} {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, name := range tfFiles {
+		if name == "main.tf" {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, name := range tfFiles {
+		if name != "main.tf" {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, name := range candidates {
+		var h ModuleDetails
+		var err error
+		fullPath := filepath.Join(path, name)
+		switch name {
+		case headerYamlFile:
+			h, err = parser.getYamlDetails(fullPath)
+		case headerMarkdownFile:
+			h, err = parser.getMarkdownDetails(fullPath)
+		default:
+			h, err = parser.getMainDetails(fullPath)
+		}
+		if err != nil {
+			return r, warnings, err
+		}
+		if h.Title == "" {
+			continue
+		}
+		if source != "" {
+			warnings = append(warnings, Warning{
+				Message:  fmt.Sprintf("module header also found in %s, using the one in %s", name, source),
+				Location: h.HeaderLoc,
+			})
+			continue
+		}
+		warnings = append(warnings, h.Warnings...)
+		r = h
+		source = name
+	}
+	return r, warnings, nil
+}
+
+// getMainDetails scans a tf file looking for a specific pattern of comment which contains the details of the file
+// outputs a struct containing these details
+func (parser *Parser) getMainDetails(path string) (ModuleDetails, error) {
+	var r ModuleDetails
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	// the header is a comment block which starts at the beginning of a line and whose first line is the title
+	loc := headerStartRe.FindStringSubmatchIndex(string(data))
+	if loc == nil {
+		return r, nil
+	}
+	body := string(data[loc[2]:])
+	end := headerEndRe.FindStringIndex(body)
+	if end == nil {
+		return r, nil
+	}
+	var h moduleHeader
+	h.loc = SourceLocation{
+		File:      filepath.Base(path),
+		StartLine: strings.Count(string(data[:loc[0]]), "\n") + 1,
+		EndLine:   strings.Count(string(data[:loc[2]+end[1]]), "\n") + 1,
+	}
+	for _, line := range strings.Split(body[:end[0]], "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.Trim(parts[0], " \t\r"))
+		value := strings.Trim(parts[1], " \t\r")
+		switch key {
+		case "title":
+			h.Title = value
+		case "desc":
+			h.Desc = value
+		case "partners":
+			h.Partners = splitList(value)
+		case "depends":
+			h.Depends = splitList(value)
+		case "owners":
+			h.Owners = splitList(value)
+		case "tags":
+			h.Tags = splitList(value)
+		case "team":
+			h.Team = value
+		case "status":
+			h.Status = value
+		case "deprecated":
+			h.Deprecated = value
+		case "replaced-by":
+			h.ReplacedBy = value
+		}
+	}
+	return h.toDetails(), nil
+}
+
+// getYamlDetails reads the module header from a module.yaml file
+func (parser *Parser) getYamlDetails(path string) (ModuleDetails, error) {
+	var h moduleHeader
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ModuleDetails{}, err
+	}
+	if err := yaml.UnmarshalStrict(data, &h); err != nil {
+		return ModuleDetails{}, fmt.Errorf("%s: %v", path, err)
+	}
+	h.loc = fileLocation(path, data)
+	if err := h.check(path); err != nil {
+		return ModuleDetails{}, err
+	}
+	return h.toDetails(), nil
+}
+
+// getMarkdownDetails reads the module header from the front matter of a README.header.md file
+// if the front matter has no description then the body of the file is used instead
+func (parser *Parser) getMarkdownDetails(path string) (ModuleDetails, error) {
+	var h moduleHeader
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ModuleDetails{}, err
+	}
+	match := frontMatterRe.FindSubmatchIndex(data)
+	if match == nil {
+		return ModuleDetails{}, fmt.Errorf("%s: no front matter found", path)
+	}
+	if err := yaml.UnmarshalStrict(data[match[2]:match[3]], &h); err != nil {
+		return ModuleDetails{}, fmt.Errorf("%s: %v", path, err)
+	}
+	if h.Desc == "" {
+		h.Desc = strings.Trim(string(data[match[1]:]), " \r\n")
+	}
+	h.loc = fileLocation(path, data)
+	if err := h.check(path); err != nil {
+		return ModuleDetails{}, err
+	}
+	return h.toDetails(), nil
+}
+
+// fileLocation covers all the lines of a file
+func fileLocation(path string, data []byte) SourceLocation {
+	return SourceLocation{
+		File:      filepath.Base(path),
+		StartLine: 1,
+		EndLine:   strings.Count(strings.TrimRight(string(data), "\r\n"), "\n") + 1,
+	}
+}
+
+// check makes sure a header read from a sidecar file has the required fields
+// unlike the comment in a tf file, a sidecar file is only there to hold the header so a bad one is an error
+func (h moduleHeader) check(path string) error {
+	if !titleRe.MatchString(h.Title) {
+		return fmt.Errorf("%s: title %q must only contain letters, numbers, underscores or hyphens", path, h.Title)
+	}
+	if h.Desc == "" {
+		return fmt.Errorf("%s: desc is missing", path)
+	}
+	return nil
+}
+
+// toDetails validates the header and converts it into module details
+// headers with an invalid title or without a description are ignored, and an unknown status is left out with a warning
+func (h moduleHeader) toDetails() ModuleDetails {
+	var r ModuleDetails
+	if !titleRe.MatchString(h.Title) || h.Desc == "" {
+		return r
+	}
+	r = ModuleDetails{
+		Title:      h.Title,
+		Desc:       h.Desc,
+		Partners:   h.Partners,
+		Depends:    h.Depends,
+		Owners:     h.Owners,
+		Team:       h.Team,
+		Status:     strings.ToLower(h.Status),
+		Tags:       h.Tags,
+		Deprecated: h.Deprecated,
+		ReplacedBy: h.ReplacedBy,
+		HeaderLoc:  h.loc,
+	}
+	if r.Status == "" && r.Deprecated != "" {
+		r.Status = StatusDeprecated
+	}
+	if r.Status != "" && !isValidStatus(r.Status) {
+		r.Warnings = append(r.Warnings, Warning{
+			Message:  fmt.Sprintf("unknown status %q, expected one of %s", r.Status, strings.Join(moduleStatuses, ", ")),
+			Location: h.loc,
+		})
+		r.Status = ""
+	}
+	return r
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"math/big"
 	"sort"
 	"strings"
 
@@ -48,6 +49,49 @@ func convertValueToString(val cty.Value) string {
 	return "ERROR: cannot convert!"
 }
 
+// convertValueToInterface recurses through cty.Value structures and converts them to plain values which can be written as json or yaml
+// whole numbers become int64 and other numbers float64, null and unknown values become nil
+func convertValueToInterface(val cty.Value) interface{} {
+	if val.IsNull() || !val.IsKnown() {
+		return nil
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString()
+	case ty == cty.Number:
+		n := val.AsBigFloat()
+		if i, accuracy := n.Int64(); accuracy == big.Exact {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	case ty == cty.Bool:
+		return val.True()
+	case ty.IsTupleType() || ty.IsListType() || ty.IsSetType():
+		ret := []interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			ret = append(ret, convertValueToInterface(v))
+		}
+		return ret
+	case ty.IsMapType():
+		ret := make(map[string]interface{})
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			ret[k.AsString()] = convertValueToInterface(v)
+		}
+		return ret
+	case ty.IsObjectType():
+		ret := make(map[string]interface{})
+		for name := range ty.AttributeTypes() {
+			ret[name] = convertValueToInterface(val.GetAttr(name))
+		}
+		return ret
+	}
+	return nil
+}
+
 // numberToString converts cty.Value number to a string representation
 func numberToString(val cty.Value) string {
 	return val.AsBigFloat().String()
@@ -71,3 +115,29 @@ func trimAll(input []string) []string {
 	}
 	return output
 }
+
+// splitList splits a comma separated header value into its trimmed, non-empty elements
+func splitList(input string) []string {
+	var output []string
+	for _, s := range trimAll(strings.Split(input, ",")) {
+		if s != "" {
+			output = append(output, s)
+		}
+	}
+	return output
+}
+
+// isValidStatus checks if a status is one of the known module maturity levels
+func isValidStatus(status string) bool {
+	return containsString(moduleStatuses, status)
+}
+
+// containsString checks if a slice of strings contains a string
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 
 	"github.com/zclconf/go-cty/cty"
 )
@@ -19,28 +20,102 @@ type Parser struct {
 	hclParser *hclparse.Parser
 }
 
+// Module maturity levels which can be set using the status field of the module header
+const (
+	StatusExperimental = "experimental"
+	StatusBeta         = "beta"
+	StatusStable       = "stable"
+	StatusDeprecated   = "deprecated"
+)
+
+var moduleStatuses = []string{StatusExperimental, StatusBeta, StatusStable, StatusDeprecated}
+
 // ModuleDetails contains the details of the module being scanned
+// Files are the names of the terraform files in the module folder, in order
 type ModuleDetails struct {
-	Title     string
-	Desc      string
-	Partners  []string
-	Depends   []string
-	Variables []VariableDetails
-	Outputs   []OutputDetails
+	Title        string
+	Desc         string
+	Partners     []string
+	Depends      []string
+	Owners       []string
+	Team         string
+	Status       string
+	Tags         []string
+	Deprecated   string
+	ReplacedBy   string
+	Warnings     []Warning
+	Variables    []VariableDetails
+	Outputs      []OutputDetails
+	Resources    []ResourceDetails
+	Locals       []LocalDetails
+	ModuleCalls  []ModuleCallDetails
+	Examples     []ExampleDetails
+	Suppressions []Suppression
+	References   map[string][]string
+	HeaderLoc    SourceLocation
+	Files        []string
+}
+
+// Warning is a problem found in a module which does not stop it being documented, Location is where it was found
+type Warning struct {
+	Message  string
+	Location SourceLocation
+}
+
+// SourceLocation records the file, relative to the module folder, and the lines an item was defined on
+type SourceLocation struct {
+	File      string
+	StartLine int
+	EndLine   int
 }
 
 // VariableDetails contains the details of the variables defined by the module
+// Doc holds any comments immediately before the variable block, which are treated as markdown
+// Def is the default as text, for the README, and DefValue is the same default as a value, which is nil when there is no default
 type VariableDetails struct {
-	Name     string
-	Desc     string
-	Def      string
-	DataType string
+	Name      string
+	Desc      string
+	Def       string
+	DefValue  interface{}
+	DataType  string
+	Sensitive bool
+	Doc       string
+	Location  SourceLocation
 }
 
 // OutputDetails contains the details of the outputs defined by the module
+// Doc holds any comments immediately before the output block, which are treated as markdown
 type OutputDetails struct {
-	Name string
-	Desc string
+	Name     string
+	Desc     string
+	Doc      string
+	Location SourceLocation
+}
+
+// LocalDetails contains the details of the local values defined by the module
+// Expr is the source of the expression which sets the value
+type LocalDetails struct {
+	Name     string
+	Expr     string
+	Doc      string
+	Location SourceLocation
+}
+
+// ResourceDetails contains the details of the resources and data sources defined by the module
+// Mode is either resource or data
+type ResourceDetails struct {
+	Mode     string
+	Type     string
+	Name     string
+	Location SourceLocation
+}
+
+// ModuleCallDetails contains the details of the module blocks which call other modules
+// Source is the source attribute, for example ../vpc for a module in the same repository
+type ModuleCallDetails struct {
+	Name     string
+	Source   string
+	Location SourceLocation
 }
 
 // New creates a new instance of Parser
@@ -61,6 +136,7 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 	if err != nil {
 		return r, err
 	}
+	var tfFiles []string
 	for _, file := range files {
 		// ignore . files
 		if strings.HasPrefix(file.Name(), ".") {
@@ -71,14 +147,7 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 		if !file.IsDir() {
 			// only look at Terraform files
 			if strings.HasSuffix(file.Name(), ".tf") {
-				// check the main.tf file for the comments
-				if strings.HasSuffix(file.Name(), "main.tf") {
-					tr, err := parser.getMainDetails(fullPath)
-					if err != nil {
-						return r, err
-					}
-					r = tr
-				}
+				tfFiles = append(tfFiles, file.Name())
 				_, diagnostics := parser.hclParser.ParseHCLFile(fullPath)
 				if diagnostics != nil && diagnostics.HasErrors() {
 					return r, diagnostics
@@ -87,14 +156,39 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 		}
 	}
 
-	// run parser on all files
+	// find the comments which describe the module
+	tr, warnings, err := parser.findHeader(path, tfFiles)
+	if err != nil {
+		return r, err
+	}
+	r = tr
+	r.Warnings = warnings
+	r.Files = tfFiles
+
+	// the examples are in folders of their own, which are not part of the module
+	r.Examples, err = findExamples(path)
+	if err != nil {
+		return r, err
+	}
+
+	// run parser on all files, in name order so the output is the same each time
 	var blocks hcl.Blocks
-	for _, file := range parser.hclParser.Files() {
+	docs := make(map[string]map[int]string)
+	var names []string
+	for name := range parser.hclParser.Files() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := parser.hclParser.Files()[name]
 		fileBlocks, err := parser.parseFile(file)
 		if err != nil {
 			return r, err
 		}
 		blocks = append(blocks, fileBlocks...)
+		var suppressions []Suppression
+		docs[name], suppressions = docComments(file.Bytes, name)
+		r.Suppressions = append(r.Suppressions, suppressions...)
 	}
 
 	// go through the variables
@@ -103,6 +197,8 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 		var varDetails VariableDetails
 		variableName := block.Labels[0]
 		varDetails.Name = variableName
+		varDetails.Doc = docs[block.DefRange.Filename][block.DefRange.Start.Line]
+		varDetails.Location = blockLocation(block)
 		// go through the attributes of the variable
 		attributes, diagnostics := block.Body.JustAttributes()
 		if diagnostics != nil && diagnostics.HasErrors() {
@@ -126,9 +222,13 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 			if attribute.Name == "description" && val.Type() == cty.String {
 				varDetails.Desc = convertValueToString(val)
 			}
-			// get default
-			if attribute.Name == "default" {
+			// get default, a default of null is the same as having no default
+			if attribute.Name == "default" && !val.IsNull() {
 				varDetails.Def = convertValueToString(val)
+				varDetails.DefValue = convertValueToInterface(val)
+			}
+			if attribute.Name == "sensitive" && val.Type() == cty.Bool && val.IsKnown() && !val.IsNull() {
+				varDetails.Sensitive = val.True()
 			}
 		}
 		v = append(v, varDetails)
@@ -140,6 +240,8 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 		var outDetails OutputDetails
 		outputName := block.Labels[0]
 		outDetails.Name = outputName
+		outDetails.Doc = docs[block.DefRange.Filename][block.DefRange.Start.Line]
+		outDetails.Location = blockLocation(block)
 		// find the description attribute if it is present
 		attributes, diagnostics := block.Body.JustAttributes()
 		if diagnostics != nil && diagnostics.HasErrors() {
@@ -154,9 +256,87 @@ func (parser *Parser) ParseModule(path string) (ModuleDetails, error) {
 		o = append(o, outDetails)
 	}
 	r.Outputs = o
+
+	// go through the resources and data sources
+	for _, block := range blocks {
+		if block.Type == "resource" || block.Type == "data" {
+			r.Resources = append(r.Resources, ResourceDetails{
+				Mode:     block.Type,
+				Type:     block.Labels[0],
+				Name:     block.Labels[1],
+				Location: blockLocation(block),
+			})
+		}
+	}
+
+	// go through the calls to other modules
+	for _, block := range blocks.OfType("module") {
+		call := ModuleCallDetails{
+			Name:     block.Labels[0],
+			Location: blockLocation(block),
+		}
+		if body, ok := block.Body.(*hclsyntax.Body); ok {
+			if source, ok := body.Attributes["source"]; ok {
+				val, _ := source.Expr.Value(ctx)
+				if val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+					call.Source = convertValueToString(val)
+				}
+			}
+		}
+		r.ModuleCalls = append(r.ModuleCalls, call)
+	}
+
+	// go through the locals and build up the graph of references between the items in the module
+	for _, block := range blocks {
+		if block.Type == "locals" {
+			attributes, diagnostics := block.Body.JustAttributes()
+			if diagnostics != nil && diagnostics.HasErrors() {
+				return r, diagnostics
+			}
+			for _, attribute := range attributes {
+				src := parser.hclParser.Files()[attribute.Range.Filename].Bytes
+				r.Locals = append(r.Locals, LocalDetails{
+					Name: attribute.Name,
+					Expr: string(attribute.Expr.Range().SliceBytes(src)),
+					Doc:  docs[attribute.Range.Filename][attribute.Range.Start.Line],
+					Location: SourceLocation{
+						File:      filepath.Base(attribute.Range.Filename),
+						StartLine: attribute.Range.Start.Line,
+						EndLine:   attribute.Range.End.Line,
+					},
+				})
+				r.addReferences("local."+attribute.Name, expressionReferences(attribute.Expr))
+			}
+			continue
+		}
+		if address := blockAddress(block); address != "" {
+			r.addReferences(address, bodyReferences(block.Body))
+		}
+	}
+	// attributes come back in a map so put the locals back in the order they were written
+	sort.Slice(r.Locals, func(i, j int) bool {
+		a, b := r.Locals[i].Location, r.Locals[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
 	return r, nil
 }
 
+// blockLocation works out the file and range of lines covered by a block
+func blockLocation(block *hcl.Block) SourceLocation {
+	loc := SourceLocation{
+		File:      filepath.Base(block.DefRange.Filename),
+		StartLine: block.DefRange.Start.Line,
+		EndLine:   block.DefRange.End.Line,
+	}
+	if body, ok := block.Body.(*hclsyntax.Body); ok {
+		loc.EndLine = body.SrcRange.End.Line
+	}
+	return loc
+}
+
 // parseFile gets the contents of the file for later use
 func (parser *Parser) parseFile(file *hcl.File) (hcl.Blocks, error) {
 	contents, diagnostics := file.Body.Content(terraformSchema)
@@ -168,40 +348,3 @@ func (parser *Parser) parseFile(file *hcl.File) (hcl.Blocks, error) {
 	}
 	return contents.Blocks, nil
 }
-
-// getMainDetails scans a tf file looking for a specific pattern of comment which contains the details of the file
-// outputs a struct containing these details
-func (parser *Parser) getMainDetails(path string) (ModuleDetails, error) {
-	var r ModuleDetails
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return r, err
-	}
-	var re = regexp.MustCompile(`(?m)^\/\*\r?\ntitle:\s+([\w\-]+)\r?\ndesc:\s+([\w\-\t \.,\/<>="';!@#$%^&*()_+~:]+)\r?\n(partners:\s+[\w\-,\s]+\r?\n)?(depends:\s+[\w\-,\s]+\r?\n)?\*\/`)
-	match := re.FindAllStringSubmatch(string(data), -1)
-	var title string
-	var desc string
-	var partners []string
-	var depends []string
-	if match == nil {
-		return r, nil
-	}
-	title = strings.Trim(match[0][1], " \r\n")
-	desc = strings.Trim(match[0][2], " \r\n")
-	for i := 3; i < len(match[0]); i++ {
-		temp := match[0][i]
-		if strings.HasPrefix(temp, "partners:") {
-			partners = trimAll(strings.Split(strings.Split(temp, ":")[1], ","))
-		}
-		if strings.HasPrefix(temp, "depends:") {
-			depends = trimAll(strings.Split(strings.Split(temp, ":")[1], ","))
-		}
-	}
-	r = ModuleDetails{
-		Title:    title,
-		Desc:     desc,
-		Partners: partners,
-		Depends:  depends,
-	}
-	return r, nil
-}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -8,10 +9,11 @@ import (
 
 func TestMainDetails(t *testing.T) {
 	want := ModuleDetails{
-		Title:    "testing",
-		Desc:     "test, test, test",
-		Partners: []string{"partner1", "partner2"},
-		Depends:  []string{"depend1", "depend2"},
+		Title:     "testing",
+		Desc:      "test, test, test",
+		Partners:  []string{"partner1", "partner2"},
+		Depends:   []string{"depend1", "depend2"},
+		HeaderLoc: SourceLocation{File: "main_test.tf", StartLine: 1, EndLine: 6},
 	}
 	got, err := New().getMainDetails("tests/main_test.tf")
 	if err != nil {
@@ -24,9 +26,10 @@ func TestMainDetails(t *testing.T) {
 
 func TestMainWithPartnersOnly(t *testing.T) {
 	want := ModuleDetails{
-		Title:    "testing",
-		Desc:     "test, test, test",
-		Partners: []string{"partner1", "partner2"},
+		Title:     "testing",
+		Desc:      "test, test, test",
+		Partners:  []string{"partner1", "partner2"},
+		HeaderLoc: SourceLocation{File: "main_test_p_only.tf", StartLine: 1, EndLine: 5},
 	}
 	got, err := New().getMainDetails("tests/main_test_p_only.tf")
 	if err != nil {
@@ -39,9 +42,10 @@ func TestMainWithPartnersOnly(t *testing.T) {
 
 func TestMainWithDependsOnly(t *testing.T) {
 	want := ModuleDetails{
-		Title:   "testing",
-		Desc:    "test, test, test",
-		Depends: []string{"depend1", "depend2"},
+		Title:     "testing",
+		Desc:      "test, test, test",
+		Depends:   []string{"depend1", "depend2"},
+		HeaderLoc: SourceLocation{File: "main_test_d_only.tf", StartLine: 1, EndLine: 5},
 	}
 	got, err := New().getMainDetails("tests/main_test_d_only.tf")
 	if err != nil {
@@ -54,8 +58,9 @@ func TestMainWithDependsOnly(t *testing.T) {
 
 func TestMainWithPunc(t *testing.T) {
 	want := ModuleDetails{
-		Title: "testing",
-		Desc:  `test with lots of punctuation <>=""':;!@#$%^&*()-_+*~.`,
+		Title:     "testing",
+		Desc:      `test with lots of punctuation <>=""':;!@#$%^&*()-_+*~.`,
+		HeaderLoc: SourceLocation{File: "main_test_punc.tf", StartLine: 1, EndLine: 4},
 	}
 	got, err := New().getMainDetails("tests/main_test_punc.tf")
 	if err != nil {
@@ -66,12 +71,358 @@ func TestMainWithPunc(t *testing.T) {
 	}
 }
 
+func TestMainWithExtendedMetadata(t *testing.T) {
+	want := ModuleDetails{
+		Title:      "testing",
+		Desc:       "test, test, test",
+		Depends:    []string{"depend1", "depend2"},
+		Owners:     []string{"alice", "bob"},
+		Team:       "platform",
+		Status:     "deprecated",
+		Tags:       []string{"networking", "aws"},
+		Deprecated: "this module will be removed in the next major release",
+		ReplacedBy: "testing-v2",
+		HeaderLoc:  SourceLocation{File: "main_test_extended.tf", StartLine: 1, EndLine: 10},
+	}
+	got, err := New().getMainDetails("tests/main_test_extended.tf")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestMainWithBadStatus(t *testing.T) {
+	got, err := New().getMainDetails("tests/main_test_bad_status.tf")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got.Title != "testing" || got.Status != "" {
+		t.Errorf("expected the module to be documented without its status, got %+v", got)
+	}
+	want := []Warning{{
+		Message:  `unknown status "finished", expected one of ` + strings.Join(moduleStatuses, ", "),
+		Location: SourceLocation{File: "main_test_bad_status.tf", StartLine: 1, EndLine: 5},
+	}}
+	if diff := deep.Equal(got.Warnings, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestHeaderInAnyFile(t *testing.T) {
+	want := ModuleDetails{
+		Files: []string{"network.tf"},
+		Title: "network",
+		Desc:  "module without a main.tf",
+		Variables: []VariableDetails{
+			VariableDetails{
+				Name:     "cidr",
+				Desc:     "the cidr block",
+				Location: SourceLocation{File: "network.tf", StartLine: 6, EndLine: 8},
+			},
+		},
+		HeaderLoc: SourceLocation{File: "network.tf", StartLine: 1, EndLine: 4},
+	}
+	got, err := New().ParseModule("tests/header_any_file/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestHeaderInYaml(t *testing.T) {
+	want := ModuleDetails{
+		Files:   []string{"variables.tf"},
+		Title:   "yaml-header",
+		Desc:    "header from a sidecar file",
+		Depends: []string{"depend1"},
+		Tags:    []string{"aws"},
+		Variables: []VariableDetails{
+			VariableDetails{
+				Name:     "cidr",
+				Desc:     "the cidr block",
+				Location: SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 3},
+			},
+		},
+		HeaderLoc: SourceLocation{File: "module.yaml", StartLine: 1, EndLine: 6},
+	}
+	got, err := New().ParseModule("tests/header_yaml/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestHeaderInMarkdown(t *testing.T) {
+	want := ModuleDetails{
+		Title:     "markdown-header",
+		Desc:      "Description from the body of the file.",
+		Status:    "beta",
+		HeaderLoc: SourceLocation{File: "README.header.md", StartLine: 1, EndLine: 5},
+	}
+	got, err := New().getMarkdownDetails("tests/header_markdown/README.header.md")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestHeaderConflict(t *testing.T) {
+	got, err := New().ParseModule("tests/header_conflict/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got.Title != "from-main" {
+		t.Errorf("expected header from main.tf, got %q", got.Title)
+	}
+	want := []Warning{{
+		Message:  "module header also found in another.tf, using the one in main.tf",
+		Location: SourceLocation{File: "another.tf", StartLine: 1, EndLine: 4},
+	}}
+	if diff := deep.Equal(got.Warnings, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	want := ModuleDetails{
+		Files: []string{"outputs.tf", "variables.tf"},
+		Title: "doc-comments",
+		Desc:  "module with documented variables",
+		Variables: []VariableDetails{
+			VariableDetails{
+				Name:     "cidr",
+				Desc:     "the cidr block",
+				Doc:      "The CIDR block for the VPC.\n\nMust not overlap with any **peered** network.",
+				Location: SourceLocation{File: "variables.tf", StartLine: 9, EndLine: 11},
+			},
+			VariableDetails{
+				Name:     "name",
+				Desc:     "the name",
+				Location: SourceLocation{File: "variables.tf", StartLine: 15, EndLine: 17},
+			},
+			VariableDetails{
+				Name:     "az_count",
+				Desc:     "number of azs",
+				Doc:      "Number of availability zones to use.",
+				Location: SourceLocation{File: "variables.tf", StartLine: 22, EndLine: 24},
+			},
+			VariableDetails{
+				Name:     "region",
+				Desc:     "the region",
+				Location: SourceLocation{File: "variables.tf", StartLine: 26, EndLine: 28},
+			},
+		},
+		Outputs: []OutputDetails{
+			OutputDetails{
+				Name:     "vpc_id",
+				Desc:     "vpc id",
+				Doc:      "The ID of the VPC, use this\nwhen creating subnets.",
+				Location: SourceLocation{File: "outputs.tf", StartLine: 3, EndLine: 6},
+			},
+		},
+		HeaderLoc: SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 4},
+	}
+	got, err := New().ParseModule("tests/doc_comments/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestResources(t *testing.T) {
+	want := ModuleDetails{
+		Files: []string{"main.tf"},
+		Resources: []ResourceDetails{
+			ResourceDetails{
+				Mode:     "resource",
+				Type:     "aws_vpc",
+				Name:     "this",
+				Location: SourceLocation{File: "main.tf", StartLine: 1, EndLine: 3},
+			},
+			ResourceDetails{
+				Mode:     "data",
+				Type:     "aws_region",
+				Name:     "current",
+				Location: SourceLocation{File: "main.tf", StartLine: 5, EndLine: 5},
+			},
+		},
+	}
+	got, err := New().ParseModule("tests/resources/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got, err := New().ParseModule("tests/references/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	wantLocals := []LocalDetails{
+		LocalDetails{
+			Name:     "full_name",
+			Expr:     `"${var.name}-vpc"`,
+			Doc:      "the name given to everything",
+			Location: SourceLocation{File: "main.tf", StartLine: 15, EndLine: 15},
+		},
+		LocalDetails{
+			Name:     "tags",
+			Expr:     "{ Name = local.full_name }",
+			Location: SourceLocation{File: "main.tf", StartLine: 16, EndLine: 16},
+		},
+	}
+	if diff := deep.Equal(got.Locals, wantLocals); diff != nil {
+		t.Error(diff)
+	}
+	wantRefs := map[string][]string{
+		"local.full_name": []string{"var.name"},
+		"local.tags":      []string{"local.full_name"},
+		"aws_vpc.this":    []string{"local.tags", "var.cidr"},
+		"output.vpc_id":   []string{"aws_vpc.this"},
+	}
+	if diff := deep.Equal(got.References, wantRefs); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(got.UnusedVariables(), []string{"unused"}); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(got.EmptyOutputs(), []string{"static"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestModuleCalls(t *testing.T) {
+	got, err := New().ParseModule("tests/module_calls/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	want := []ModuleCallDetails{
+		ModuleCallDetails{
+			Name:     "network",
+			Source:   "../vpc",
+			Location: SourceLocation{File: "main.tf", StartLine: 1, EndLine: 4},
+		},
+		ModuleCallDetails{
+			Name:     "dns",
+			Source:   "terraform-aws-modules/route53/aws",
+			Location: SourceLocation{File: "main.tf", StartLine: 6, EndLine: 9},
+		},
+	}
+	if diff := deep.Equal(got.ModuleCalls, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestExamples(t *testing.T) {
+	got, err := New().ParseModule("tests/examples/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	want := []ExampleDetails{
+		ExampleDetails{
+			Name:   "basic",
+			Folder: "examples/basic",
+			Files:  []string{"main.tf", "outputs.tf"},
+			Main:   "module \"thing\" {\n  source = \"../..\"\n  name   = \"basic\"\n}\n",
+		},
+		ExampleDetails{
+			Name:   "complete",
+			Folder: "examples/complete",
+			Files:  []string{"providers.tf"},
+		},
+	}
+	if diff := deep.Equal(got.Examples, want); diff != nil {
+		t.Error(diff)
+	}
+	if len(got.Variables) != 1 || len(got.Outputs) != 0 {
+		t.Errorf("the examples should not be parsed as part of the module, got %d variables and %d outputs", len(got.Variables), len(got.Outputs))
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	got, err := New().ParseModule("tests/suppressions/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	wantVariables := []VariableDetails{
+		VariableDetails{
+			Name:     "name",
+			Desc:     "the name",
+			Doc:      "The name of the thing.",
+			Location: SourceLocation{File: "main.tf", StartLine: 5, EndLine: 7},
+		},
+		VariableDetails{
+			Name:      "password",
+			Def:       "hunter2",
+			DefValue:  "hunter2",
+			Sensitive: true,
+			Location:  SourceLocation{File: "main.tf", StartLine: 9, EndLine: 12},
+		},
+	}
+	if diff := deep.Equal(got.Variables, wantVariables); diff != nil {
+		t.Error(diff)
+	}
+	wantSuppressions := []Suppression{
+		Suppression{File: "main.tf", Line: 1, Module: true, Rules: []string{"title-folder"}},
+		Suppression{File: "main.tf", Line: 5, Rules: []string{"variable-type", "description-punctuation"}},
+		Suppression{File: "main.tf", Line: 10, Rules: []string{"sensitive-default"}},
+	}
+	if diff := deep.Equal(got.Suppressions, wantSuppressions); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestNullDefault(t *testing.T) {
+	want := []VariableDetails{
+		VariableDetails{
+			Name:      "password",
+			DataType:  "string",
+			Sensitive: true,
+			Location:  SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 5},
+		},
+		VariableDetails{
+			Name:     "region",
+			DataType: "string",
+			Location: SourceLocation{File: "variables.tf", StartLine: 7, EndLine: 10},
+		},
+		VariableDetails{
+			Name:     "api_token",
+			DataType: "string",
+			Def:      "changeme",
+			DefValue: "changeme",
+			Location: SourceLocation{File: "variables.tf", StartLine: 12, EndLine: 15},
+		},
+	}
+	got, err := New().ParseModule("tests/null_default/")
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if diff := deep.Equal(got.Variables, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestSimpleVariable(t *testing.T) {
 	want := ModuleDetails{
+		Files: []string{"variables.tf"},
 		Variables: []VariableDetails{
 			VariableDetails{
-				Name: "test",
-				Desc: "testing variable with no type",
+				Name:     "test",
+				Desc:     "testing variable with no type",
+				Location: SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 3},
 			},
 		},
 	}
@@ -86,24 +437,31 @@ func TestSimpleVariable(t *testing.T) {
 
 func TestTypedVariables(t *testing.T) {
 	want := ModuleDetails{
+		Files: []string{"variables.tf"},
 		Variables: []VariableDetails{
 			VariableDetails{
 				Name:     "test_string",
 				Desc:     "this is a string",
 				DataType: "string",
 				Def:      "string",
+				DefValue: "string",
+				Location: SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 5},
 			},
 			VariableDetails{
 				Name:     "test_number",
 				Desc:     "this is a number",
 				DataType: "number",
 				Def:      "10",
+				DefValue: int64(10),
+				Location: SourceLocation{File: "variables.tf", StartLine: 7, EndLine: 11},
 			},
 			VariableDetails{
 				Name:     "test_bool",
 				Desc:     "this is a bool",
 				DataType: "bool",
 				Def:      "true",
+				DefValue: true,
+				Location: SourceLocation{File: "variables.tf", StartLine: 13, EndLine: 17},
 			},
 		},
 	}
@@ -118,60 +476,79 @@ func TestTypedVariables(t *testing.T) {
 
 func TestComplexVariables(t *testing.T) {
 	want := ModuleDetails{
+		Files: []string{"variables.tf"},
 		Variables: []VariableDetails{
 			VariableDetails{
 				Name:     "test_string_list",
 				Desc:     "list of strings",
 				DataType: "list(string)",
 				Def:      "[one, two, three]",
+				DefValue: []interface{}{"one", "two", "three"},
+				Location: SourceLocation{File: "variables.tf", StartLine: 1, EndLine: 5},
 			},
 			VariableDetails{
 				Name:     "test_number_list",
 				Desc:     "list of numbers",
 				DataType: "list(number)",
 				Def:      "[1, 2, 3]",
+				DefValue: []interface{}{int64(1), int64(2), int64(3)},
+				Location: SourceLocation{File: "variables.tf", StartLine: 7, EndLine: 11},
 			},
 			VariableDetails{
 				Name:     "test_bool_list",
 				Desc:     "list of bools",
 				DataType: "list(bool)",
 				Def:      "[true, false]",
+				DefValue: []interface{}{true, false},
+				Location: SourceLocation{File: "variables.tf", StartLine: 13, EndLine: 17},
 			},
 			VariableDetails{
 				Name:     "test_tuple_mv",
 				Desc:     "multi-value tuple",
 				DataType: "tuple([string,number,bool])",
 				Def:      "[test, 1, true]",
+				DefValue: []interface{}{"test", int64(1), true},
+				Location: SourceLocation{File: "variables.tf", StartLine: 19, EndLine: 23},
 			},
 			VariableDetails{
 				Name:     "test_string_map",
 				Desc:     "test map for strings",
 				DataType: "map(string)",
 				Def:      "{a=ay, b=bee, c=cee}",
+				DefValue: map[string]interface{}{"a": "ay", "b": "bee", "c": "cee"},
+				Location: SourceLocation{File: "variables.tf", StartLine: 25, EndLine: 33},
 			},
 			VariableDetails{
 				Name:     "test_object",
 				Desc:     "test object",
 				DataType: "object({a=string,b=number,c=bool})",
 				Def:      "{a=ay, b=10, c=false}",
+				DefValue: map[string]interface{}{"a": "ay", "b": int64(10), "c": false},
+				Location: SourceLocation{File: "variables.tf", StartLine: 35, EndLine: 47},
 			},
 			VariableDetails{
 				Name:     "test_string_set",
 				Desc:     "set of strings",
 				DataType: "set(string)",
 				Def:      "[one, two, three]",
+				DefValue: []interface{}{"one", "two", "three"},
+				Location: SourceLocation{File: "variables.tf", StartLine: 49, EndLine: 53},
 			},
 			VariableDetails{
 				Name:     "test_list_of_objects",
 				Desc:     "test list of objects",
 				DataType: "list(object({a=string,b=number,c=bool}))",
 				Def:      "[{a=ay, b=10, c=false}, {d=dee, e=20, f=true}]",
+				DefValue: []interface{}{map[string]interface{}{"a": "ay", "b": int64(10), "c": false}, map[string]interface{}{"d": "dee", "e": int64(20), "f": true}},
+				Location: SourceLocation{File: "variables.tf", StartLine: 55, EndLine: 72},
 			},
 			VariableDetails{
 				Name:     "test_object_with_list",
 				Desc:     "test object with a list",
 				DataType: "object({a=list(string)})",
 				Def:      "{a=[a, b, c]}",
+				DefValue: map[string]interface{}{"a": []interface{}{"a", "b", "c"}},
+				Location: SourceLocation{File: "variables.tf", StartLine: 74, EndLine: 82},
 			},
 		},
 	}
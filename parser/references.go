@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// blockAddress works out the address used to refer to a block, e.g. var.name or aws_vpc.this
+// returns an empty string for blocks which cannot be referred to
+func blockAddress(block *hcl.Block) string {
+	switch block.Type {
+	case "variable":
+		return "var." + block.Labels[0]
+	case "output":
+		return "output." + block.Labels[0]
+	case "module":
+		return "module." + block.Labels[0]
+	case "provider":
+		return "provider." + block.Labels[0]
+	case "resource":
+		return block.Labels[0] + "." + block.Labels[1]
+	case "data":
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	}
+	return ""
+}
+
+// traversalAddress converts a reference found in an expression into the address of the item it refers to
+// returns an empty string for references to things which are not defined in the module, like count.index
+func traversalAddress(traversal hcl.Traversal) string {
+	var names []string
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			names = append(names, s.Name)
+		case hcl.TraverseAttr:
+			names = append(names, s.Name)
+		}
+		if len(names) == 3 {
+			break
+		}
+	}
+	if len(names) < 2 {
+		return ""
+	}
+	switch names[0] {
+	case "count", "each", "self", "path", "terraform":
+		return ""
+	case "var", "local", "module":
+		return names[0] + "." + names[1]
+	case "data":
+		if len(names) < 3 {
+			return ""
+		}
+		return "data." + names[1] + "." + names[2]
+	}
+	return names[0] + "." + names[1]
+}
+
+// bodyReferences finds the addresses of everything referred to by the expressions in a block body, including nested blocks
+func bodyReferences(body hcl.Body) []string {
+	var refs []string
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return refs
+	}
+	for _, attribute := range syntaxBody.Attributes {
+		refs = append(refs, expressionReferences(attribute.Expr)...)
+	}
+	for _, block := range syntaxBody.Blocks {
+		refs = append(refs, bodyReferences(block.Body)...)
+	}
+	return refs
+}
+
+// expressionReferences finds the addresses of everything referred to by an expression
+func expressionReferences(expr hcl.Expression) []string {
+	var refs []string
+	for _, traversal := range expr.Variables() {
+		if address := traversalAddress(traversal); address != "" {
+			refs = append(refs, address)
+		}
+	}
+	return refs
+}
+
+// addReferences records that from refers to each of refs, ignoring duplicates and self references
+func (r *ModuleDetails) addReferences(from string, refs []string) {
+	for _, to := range refs {
+		if to == from || containsString(r.References[from], to) {
+			continue
+		}
+		if r.References == nil {
+			r.References = make(map[string][]string)
+		}
+		r.References[from] = append(r.References[from], to)
+	}
+	sort.Strings(r.References[from])
+}
+
+// UnusedVariables lists the variables which are declared but never referred to
+func (r ModuleDetails) UnusedVariables() []string {
+	used := make(map[string]bool)
+	for _, refs := range r.References {
+		for _, to := range refs {
+			used[to] = true
+		}
+	}
+	var unused []string
+	for _, variable := range r.Variables {
+		if !used["var."+variable.Name] {
+			unused = append(unused, variable.Name)
+		}
+	}
+	return unused
+}
+
+// EmptyOutputs lists the outputs which do not refer to anything in the module
+func (r ModuleDetails) EmptyOutputs() []string {
+	var empty []string
+	for _, output := range r.Outputs {
+		if len(r.References["output."+output.Name]) == 0 {
+			empty = append(empty, output.Name)
+		}
+	}
+	return empty
+}
@@ -0,0 +1,6 @@
+// The ID of the VPC, use this
+// when creating subnets.
+output "vpc_id" {
+  description = "vpc id"
+  value       = "vpc-1234"
+}
@@ -0,0 +1,28 @@
+/*
+title: doc-comments
+desc: module with documented variables
+*/
+
+# The CIDR block for the VPC.
+#
+# Must not overlap with any **peered** network.
+variable "cidr" {
+  description = "the cidr block"
+}
+
+// not attached because of the blank line
+
+variable "name" {
+  description = "the name" # trailing comment
+}
+
+/*
+ * Number of availability zones to use.
+ */
+variable "az_count" {
+  description = "number of azs"
+}
+
+variable "region" {
+  description = "the region"
+}
@@ -0,0 +1 @@
+ignored
@@ -0,0 +1,4 @@
+module "thing" {
+  source = "../.."
+  name   = "basic"
+}
@@ -0,0 +1,3 @@
+variable "name" {
+  description = "the name"
+}
@@ -0,0 +1,8 @@
+/*
+title: network
+desc: module without a main.tf
+*/
+
+variable "cidr" {
+  description = "the cidr block"
+}
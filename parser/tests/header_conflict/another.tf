@@ -0,0 +1,4 @@
+/*
+title: from-another
+desc: header in another.tf
+*/
@@ -0,0 +1,4 @@
+/*
+title: from-main
+desc: header in main.tf
+*/
@@ -0,0 +1,3 @@
+variable "cidr" {
+  description = "the cidr block"
+}
@@ -0,0 +1,9 @@
+/*
+title: testing
+desc: test, test, test
+status: finished
+*/
+
+provider "aws" {
+  region = var.aws_region
+}
@@ -0,0 +1,14 @@
+/*
+title: testing
+desc: test, test, test
+depends: depend1, depend2
+owners: alice, bob
+team: platform
+tags: networking, aws
+deprecated: this module will be removed in the next major release
+replaced-by: testing-v2
+*/
+
+provider "aws" {
+  region = var.aws_region
+}
@@ -0,0 +1,9 @@
+module "network" {
+  source = "../vpc"
+  cidr   = "10.0.0.0/16"
+}
+
+module "dns" {
+  source  = "terraform-aws-modules/route53/aws"
+  version = "~> 2.0"
+}
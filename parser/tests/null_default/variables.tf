@@ -0,0 +1,15 @@
+variable "password" {
+  type      = string
+  sensitive = true
+  default   = null
+}
+
+variable "region" {
+  type    = string
+  default = null
+}
+
+variable "api_token" {
+  type    = string
+  default = "changeme"
+}
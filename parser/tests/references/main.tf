@@ -0,0 +1,32 @@
+variable "name" {
+  description = "used by a local"
+}
+
+variable "cidr" {
+  description = "used by a resource"
+}
+
+variable "unused" {
+  description = "never used"
+}
+
+locals {
+  # the name given to everything
+  full_name = "${var.name}-vpc"
+  tags      = { Name = local.full_name }
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+  tags       = local.tags
+}
+
+output "vpc_id" {
+  description = "id of the vpc"
+  value       = aws_vpc.this.id
+}
+
+output "static" {
+  description = "refers to nothing"
+  value       = "static"
+}
@@ -0,0 +1,5 @@
+resource "aws_vpc" "this" {
+  cidr_block = "10.0.0.0/16"
+}
+
+data "aws_region" "current" {}
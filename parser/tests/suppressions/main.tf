@@ -0,0 +1,12 @@
+# tf-auto-document:ignore-module title-folder
+
+# The name of the thing.
+# tf-auto-document:ignore variable-type, description-punctuation
+variable "name" {
+  description = "the name"
+}
+
+variable "password" {
+  default   = "hunter2" # tf-auto-document:ignore sensitive-default
+  sensitive = true
+}
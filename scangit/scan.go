@@ -3,6 +3,7 @@ package scangit
 import (
 	"encoding/hex"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
@@ -43,7 +44,7 @@ func (scanner *ScanGit) GetCommits(subpath string) ([]GitCommit, error) {
 	var r []GitCommit
 	lopts := git.LogOptions{
 		PathFilter: func(s string) bool {
-			if strings.HasPrefix(s, subpath) && (strings.HasSuffix(s, "tf") || strings.HasSuffix(s, "TF")) {
+			if inFolder(s, subpath) && (strings.HasSuffix(s, "tf") || strings.HasSuffix(s, "TF")) {
 				return true
 			}
 			return false
@@ -64,6 +65,26 @@ func (scanner *ScanGit) GetCommits(subpath string) ([]GitCommit, error) {
 	return r, nil
 }
 
+// inFolder checks if a file in the repo is in a folder, or one of its subfolders, rather than a folder whose name starts the same
+// the folder is relative to the root of the repo, which is "." or empty
+func inFolder(file string, folder string) bool {
+	folder = strings.TrimSuffix(folder, "/")
+	if folder == "" || folder == "." {
+		return true
+	}
+	return strings.HasPrefix(file, folder+"/")
+}
+
+// HeadCommit gets the hash of the commit which is currently checked out
+func (scanner *ScanGit) HeadCommit() (string, error) {
+	ref, err := scanner.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	hash := ref.Hash()
+	return hex.EncodeToString(hash[:]), nil
+}
+
 // LoadTags populates an in-memory list of tags for later use
 func (scanner *ScanGit) LoadTags() error {
 	tags, err := scanner.repo.Tags()
@@ -75,7 +96,7 @@ func (scanner *ScanGit) LoadTags() error {
 		tag, err := scanner.repo.TagObject(hash)
 		if err != nil {
 			// nothing to do
-			fmt.Printf("error on this tag %s\n", hex.EncodeToString(hash[:]))
+			fmt.Fprintf(os.Stderr, "error on this tag %s\n", hex.EncodeToString(hash[:]))
 		} else {
 			target := tag.Target
 			scanner.tags[hex.EncodeToString(target[:])] = tag.Name
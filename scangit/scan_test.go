@@ -0,0 +1,26 @@
+package scangit
+
+import (
+	"testing"
+)
+
+func TestInFolder(t *testing.T) {
+	cases := []struct {
+		file   string
+		folder string
+		want   bool
+	}{
+		{"modules/aws/vpc/main.tf", "modules/aws/vpc", true},
+		{"modules/aws/vpc/modules/endpoints/main.tf", "modules/aws/vpc", true},
+		{"modules/aws/vpc-endpoints/main.tf", "modules/aws/vpc", false},
+		{"modules/aws/vpc.tf", "modules/aws/vpc", false},
+		{"modules/aws/vpc/main.tf", "modules/aws/vpc/", true},
+		{"main.tf", ".", true},
+		{"main.tf", "", true},
+	}
+	for _, c := range cases {
+		if got := inFolder(c.file, c.folder); got != c.want {
+			t.Errorf("inFolder(%q, %q) got %v, want %v", c.file, c.folder, got, c.want)
+		}
+	}
+}
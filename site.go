@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// siteEntry is a module in the navigation and search index of the site
+type siteEntry struct {
+	Title     string   `json:"title"`
+	Desc      string   `json:"description"`
+	Status    string   `json:"status"`
+	Tags      []string `json:"tags"`
+	Variables []string `json:"variables"`
+	URL       string   `json:"url"`
+}
+
+// sitePage is the data passed to the layout of each page of the site
+// Heading and Intro are the title of the site and the paragraphs under it on the index page
+type sitePage struct {
+	Title    string
+	Heading  string
+	Intro    []string
+	Root     string
+	Current  string
+	Modules  []siteEntry
+	Tags     []string
+	Statuses []string
+	Content  template.HTML
+	// Diagrams is set when the content has mermaid diagrams, which are drawn by mermaid.js loaded from Mermaid
+	Diagrams bool
+	Mermaid  string
+}
+
+// siteIntro is the paragraph under the heading of the index page of the site, when no intro is configured
+var siteIntro = []string{"This is a collection of terraform modules.  This documentation is auto-generated from the terraform files using tf-auto-document."}
+
+// siteMermaidFile is the name mermaid.js is copied to in the site, when it is given as a file
+const siteMermaidFile = "mermaid.min.js"
+
+// siteURL is the path of the page for a module, relative to the root of the site
+func siteURL(folder string) string {
+	return filepath.ToSlash(folder) + "/README.html"
+}
+
+// siteRoot is the relative path from the page of a module back to the root of the site
+func siteRoot(folder string) string {
+	return strings.Repeat("../", len(strings.Split(filepath.ToSlash(folder), "/")))
+}
+
+// createSite writes a static html site to siteDir, with an index page, a page for each module and a search index
+// the terraform files of each module are copied alongside its page so that the links to source work offline
+func createSite(path string, siteDir string, details []CombinedModuleDetails, opts readmeOptions) error {
+	layout, err := template.New("site").Parse(siteLayout)
+	if err != nil {
+		return err
+	}
+
+	// build the navigation and search index
+	entries := []siteEntry{}
+	tags := make(map[string]bool)
+	statuses := make(map[string]bool)
+	for _, module := range details {
+		m := module.TFDetails
+		if m.Title == "" {
+			fmt.Fprintf(progress, "error no details found for module in %s\n", module.Folder)
+			continue
+		}
+		entry := siteEntry{
+			Title:     m.Title,
+			Desc:      m.Desc,
+			Status:    m.Status,
+			Tags:      nonNil(m.Tags),
+			Variables: []string{},
+			URL:       siteURL(module.Folder),
+		}
+		for _, v := range m.Variables {
+			entry.Variables = append(entry.Variables, v.Name)
+		}
+		for _, t := range m.Tags {
+			tags[t] = true
+		}
+		if m.Status != "" {
+			statuses[m.Status] = true
+		}
+		entries = append(entries, entry)
+	}
+	page := sitePage{Heading: opts.title(), Intro: opts.rootIntro, Modules: entries}
+	if len(page.Intro) == 0 {
+		page.Intro = siteIntro
+	}
+	for t := range tags {
+		page.Tags = append(page.Tags, t)
+	}
+	sort.Strings(page.Tags)
+	for s := range statuses {
+		page.Statuses = append(page.Statuses, s)
+	}
+	sort.Strings(page.Statuses)
+
+	// mermaid.js is copied into the site when it is a file, so that the site works without a network connection
+	mermaid := opts.mermaidScript
+	local := mermaid != "" && !strings.HasPrefix(mermaid, "http://") && !strings.HasPrefix(mermaid, "https://")
+	if local {
+		data, err := ioutil.ReadFile(mermaid)
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(filepath.Join(siteDir, siteMermaidFile), data); err != nil {
+			return err
+		}
+	}
+
+	// a page for each module with a header, which are the only modules linked to
+	graph := newModuleGraph(details)
+	opts.headersOnly = true
+	for _, module := range details {
+		if module.TFDetails.Title == "" {
+			continue
+		}
+		content, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(module, graph, opts))
+		if err != nil {
+			return err
+		}
+		page.Title = module.TFDetails.Title
+		page.Root = siteRoot(module.Folder)
+		page.Current = siteURL(module.Folder)
+		page.Content = template.HTML(content)
+		page.Diagrams = strings.Contains(content, `<pre class="mermaid">`)
+		page.Mermaid = mermaid
+		if local {
+			page.Mermaid = page.Root + siteMermaidFile
+		}
+		if err := writeSitePage(layout, filepath.Join(siteDir, filepath.FromSlash(page.Current)), page); err != nil {
+			return err
+		}
+		if err := copyModuleSources(path, module, filepath.Join(siteDir, module.Folder)); err != nil {
+			return err
+		}
+	}
+
+	// the index page, which lists every module
+	page.Title = page.Heading
+	page.Root = ""
+	page.Current = "index.html"
+	page.Content = ""
+	page.Diagrams = false
+	if err := writeSitePage(layout, filepath.Join(siteDir, "index.html"), page); err != nil {
+		return err
+	}
+
+	// the search index, also as a script so that it can be loaded when the site is opened from disk
+	index, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeOutputFile(filepath.Join(siteDir, "search.json"), index); err != nil {
+		return err
+	}
+	if err := writeOutputFile(filepath.Join(siteDir, "search.js"), []byte("var searchIndex = "+string(index)+";\n")); err != nil {
+		return err
+	}
+	if err := writeOutputFile(filepath.Join(siteDir, "site.js"), []byte(siteScript)); err != nil {
+		return err
+	}
+	return writeOutputFile(filepath.Join(siteDir, "site.css"), []byte(siteStyle))
+}
+
+// writeSitePage runs the layout for a page and writes it to file
+func writeSitePage(layout *template.Template, file string, page sitePage) error {
+	var b strings.Builder
+	if err := layout.Execute(&b, page); err != nil {
+		return err
+	}
+	return writeOutputFile(file, []byte(b.String()))
+}
+
+// writeOutputFile writes a file, creating the folder it is in
+func writeOutputFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "Writing %s\n", file)
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// copyModuleSources copies the terraform files of a module and its examples to a folder, so that the links to them from its page work
+func copyModuleSources(path string, module CombinedModuleDetails, to string) error {
+	if err := copySourceFiles(filepath.Join(path, module.Folder), to); err != nil {
+		return err
+	}
+	for _, example := range module.TFDetails.Examples {
+		if err := copySourceFiles(filepath.Join(path, module.Folder, example.Folder), filepath.Join(to, filepath.FromSlash(example.Folder))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copySourceFiles copies the terraform files in a folder to another folder
+func copySourceFiles(from string, to string) error {
+	files, err := filepath.Glob(filepath.Join(from, "*.tf"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(filepath.Join(to, filepath.Base(file)), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// siteLayout is the html page wrapping the index and module pages, with the sidebar and search
+const siteLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<link rel="stylesheet" href="{{ .Root }}site.css">
+</head>
+<body>
+<nav class="sidebar">
+<a class="home" href="{{ .Root }}index.html">{{ .Heading }}</a>
+<input type="search" id="search" placeholder="Search modules" aria-label="Search modules">
+<ul>
+{{- range .Modules }}
+<li data-module="{{ .URL }}"{{ if eq .URL $.Current }} class="current"{{ end }}><a href="{{ $.Root }}{{ .URL }}">{{ .Title }}</a></li>
+{{- end }}
+</ul>
+</nav>
+<main>
+{{- if .Content }}
+{{ .Content }}
+{{- else }}
+<h1>{{ .Heading }}</h1>
+{{- range .Intro }}
+<p>{{ . }}</p>
+{{- end }}
+<div class="filters">
+<label>Status <select id="status"><option value="">All</option>{{ range .Statuses }}<option>{{ . }}</option>{{ end }}</select></label>
+<label>Tag <select id="tag"><option value="">All</option>{{ range .Tags }}<option>{{ . }}</option>{{ end }}</select></label>
+</div>
+<table class="modules">
+<tr><th>Module</th><th>Description</th><th>Status</th><th>Tags</th></tr>
+{{- range .Modules }}
+<tr data-module="{{ .URL }}"><td><a href="{{ .URL }}">{{ .Title }}</a></td><td>{{ .Desc }}</td><td>{{ .Status }}</td><td>{{ range $i, $t := .Tags }}{{ if $i }}, {{ end }}{{ $t }}{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</main>
+<script src="{{ .Root }}search.js"></script>
+<script src="{{ .Root }}site.js"></script>
+{{- if and .Diagrams .Mermaid }}
+<script src="{{ .Mermaid }}"></script>
+<script>mermaid.initialize({startOnLoad: true});</script>
+{{- end }}
+</body>
+</html>
+`
+
+// siteScript filters the modules listed on a page by the search text, status and tag
+const siteScript = `(function () {
+  var search = document.getElementById("search");
+  var status = document.getElementById("status");
+  var tag = document.getElementById("tag");
+  var modules = {};
+  searchIndex.forEach(function (m) {
+    modules[m.url] = m;
+  });
+
+  function matches(m, query) {
+    if (status && status.value && m.status !== status.value) {
+      return false;
+    }
+    if (tag && tag.value && m.tags.indexOf(tag.value) === -1) {
+      return false;
+    }
+    if (!query) {
+      return true;
+    }
+    var text = [m.title, m.description].concat(m.tags, m.variables).join(" ").toLowerCase();
+    return query.split(/\s+/).every(function (word) {
+      return text.indexOf(word) !== -1;
+    });
+  }
+
+  function filter() {
+    var query = search.value.trim().toLowerCase();
+    var items = document.querySelectorAll("[data-module]");
+    for (var i = 0; i < items.length; i++) {
+      var m = modules[items[i].getAttribute("data-module")];
+      items[i].style.display = m && matches(m, query) ? "" : "none";
+    }
+  }
+
+  [search, status, tag].forEach(function (input) {
+    if (input) {
+      input.addEventListener("input", filter);
+    }
+  });
+})();
+`
+
+// siteStyle is the stylesheet for the site
+const siteStyle = `body {
+  margin: 0;
+  display: flex;
+  font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif;
+  line-height: 1.5;
+  color: #24292e;
+}
+.sidebar {
+  flex: 0 0 16em;
+  min-height: 100vh;
+  padding: 1em;
+  background: #f6f8fa;
+  border-right: 1px solid #e1e4e8;
+}
+.sidebar .home {
+  display: block;
+  font-weight: bold;
+  margin-bottom: 1em;
+}
+.sidebar input {
+  width: 100%;
+  box-sizing: border-box;
+}
+.sidebar ul {
+  list-style: none;
+  padding: 0;
+}
+.sidebar .current a {
+  font-weight: bold;
+}
+main {
+  flex: 1;
+  padding: 1em 2em;
+  max-width: 60em;
+}
+a {
+  color: #0366d6;
+}
+table {
+  border-collapse: collapse;
+  margin: 1em 0;
+}
+th, td {
+  border: 1px solid #dfe2e5;
+  padding: 0.4em 0.8em;
+  text-align: left;
+  vertical-align: top;
+}
+code {
+  background: #f3f4f4;
+  padding: 0.1em 0.3em;
+}
+blockquote {
+  margin: 1em 0;
+  padding: 0 1em;
+  border-left: 0.25em solid #dfe2e5;
+  color: #6a737d;
+}
+.filters label {
+  margin-right: 1em;
+}
+`
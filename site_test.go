@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestSiteURLs(t *testing.T) {
+	cases := []struct {
+		folder string
+		url    string
+		root   string
+	}{
+		{"modules/vpc", "modules/vpc/README.html", "../../"},
+		{"modules/aws/vpc/modules/subnet", "modules/aws/vpc/modules/subnet/README.html", "../../../../../"},
+	}
+	for _, c := range cases {
+		if got := siteURL(c.folder); got != c.url {
+			t.Errorf("siteURL(%q) got %q, want %q", c.folder, got, c.url)
+		}
+		if got := siteRoot(c.folder); got != c.root {
+			t.Errorf("siteRoot(%q) got %q, want %q", c.folder, got, c.root)
+		}
+	}
+}
+
+func TestCreateSite(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	for _, folder := range []string{"modules/vpc", "modules/subnet"} {
+		if err := os.MkdirAll(filepath.Join(repo, folder), 0755); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(repo, folder, "main.tf"), []byte("# "+folder+"\n"), 0644); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	details := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Desc: "Subnets.", Depends: []string{"vpc"}}},
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc", Desc: "A vpc.", Tags: []string{"aws"}}},
+		{Folder: "modules/nothing", TFDetails: parser.ModuleDetails{ModuleCalls: []parser.ModuleCallDetails{{Name: "vpc", Source: "../vpc"}}}},
+	}
+	site := filepath.Join(repo, "site")
+	if err := createSite(repo, site, details, testOptions(t, "html")); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	read := func(file string) string {
+		data, err := ioutil.ReadFile(filepath.Join(site, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		return string(data)
+	}
+
+	// the module pages are in the layout of the site
+	page := read("modules/subnet/README.html")
+	if !strings.Contains(page, `<a href="../vpc/README.html">vpc</a>`) || !strings.Contains(page, `<li data-module="modules/subnet/README.html" class="current">`) {
+		t.Errorf("module page should have its content and show it is the current page\n%s", page)
+	}
+	// without mermaid.js the graph on a module page is shown as its source, so nothing is loaded from the network
+	if !strings.Contains(page, `<pre class="mermaid">`) || strings.Contains(page, "<script src=\"http") || strings.Contains(page, "mermaid.initialize") {
+		t.Errorf("module page should have a mermaid diagram and not load mermaid.js\n%s", page)
+	}
+	if !strings.Contains(page, `href="../../search.js"`) && !strings.Contains(page, `src="../../search.js"`) {
+		t.Errorf("module page should load the search index from the root of the site\n%s", page)
+	}
+	// a module without a header has no page, so it is named without a link
+	page = read("modules/vpc/README.html")
+	if !strings.Contains(page, "<li>nothing</li>") || strings.Contains(page, "../nothing/README.html") {
+		t.Errorf("module page should name the module without a header as plain text\n%s", page)
+	}
+	index := read("index.html")
+	if strings.Contains(index, "mermaid.min.js") {
+		t.Errorf("index page has no diagrams so should not load mermaid.js")
+	}
+	for _, url := range []string{"modules/subnet/README.html", "modules/vpc/README.html"} {
+		if !strings.Contains(index, `href="`+url+`"`) {
+			t.Errorf("index page should link to %s", url)
+		}
+	}
+
+	// the search index lists the modules with a header
+	var entries []siteEntry
+	if err := json.Unmarshal([]byte(read("search.json")), &entries); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if len(entries) != 2 || entries[0].Title != "subnet" || entries[1].URL != "modules/vpc/README.html" || entries[1].Tags[0] != "aws" {
+		t.Errorf("unexpected search index %+v", entries)
+	}
+	if !strings.HasPrefix(read("search.js"), "var searchIndex = ") {
+		t.Errorf("search.js should set searchIndex")
+	}
+
+	// the sources are copied so the links to them work
+	if got := read("modules/vpc/main.tf"); got != "# modules/vpc\n" {
+		t.Errorf("got %q for the copied source", got)
+	}
+}
+
+func TestCreateSiteMermaid(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	script := filepath.Join(repo, "mermaid.min.js")
+	if err := ioutil.WriteFile(script, []byte("var mermaid = {};\n"), 0644); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	details := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc"}}},
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+	}
+	cases := []struct {
+		script string
+		src    string
+	}{
+		// a file is copied into the site so that it works without a network connection
+		{script, `<script src="../../mermaid.min.js"></script>`},
+		{"https://cdn.example.com/mermaid.min.js", `<script src="https://cdn.example.com/mermaid.min.js"></script>`},
+	}
+	for _, c := range cases {
+		site := filepath.Join(repo, "site")
+		opts := testOptions(t, "html")
+		opts.mermaidScript = c.script
+		if err := createSite(repo, site, details, opts); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(site, "modules", "subnet", "README.html"))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if !strings.Contains(string(data), c.src) || !strings.Contains(string(data), "mermaid.initialize") {
+			t.Errorf("module page should load mermaid.js with %s\n%s", c.src, data)
+		}
+		if _, err := os.Stat(filepath.Join(site, siteMermaidFile)); (err == nil) != (c.script == script) {
+			t.Errorf("mermaid.js should only be copied into the site when it is a file, %s", c.script)
+		}
+		os.RemoveAll(site)
+	}
+}
+
+func TestCreateSiteTitle(t *testing.T) {
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(repo)
+	details := []CombinedModuleDetails{{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}}}
+	opts := testOptions(t, "html")
+	opts.rootTitle = "Platform Modules"
+	opts.rootIntro = []string{"Modules for the platform team.", "Ask in #platform."}
+	site := filepath.Join(repo, "site")
+	if err := createSite(repo, site, details, opts); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(site, "index.html"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	index := string(data)
+	for _, want := range []string{"<title>Platform Modules</title>", `index.html">Platform Modules</a>`, "<h1>Platform Modules</h1>", "<p>Modules for the platform team.</p>\n<p>Ask in #platform.</p>"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("want %q in\n%s", want, index)
+		}
+	}
+	if strings.Contains(index, "Terraform Modules") {
+		t.Errorf("the configured title should replace the default\n%s", index)
+	}
+}
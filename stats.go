@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// statsCount is how many of a kind of item are documented
+type statsCount struct {
+	Count   int     `json:"count"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// noItems is the count before any items are added, with nothing to document counting as fully documented
+var noItems = statsCount{Percent: 100}
+
+// add counts an item, which is documented if ok is set
+func (c *statsCount) add(ok bool) {
+	c.Total++
+	if ok {
+		c.Count++
+	}
+	c.Percent = percent(c.Count, c.Total)
+}
+
+// String formats a count for the table, e.g. 3/4 (75%)
+func (c statsCount) String() string {
+	if c.Total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.0f%%)", c.Count, c.Total, c.Percent)
+}
+
+// percent works out a percentage to one decimal place, nothing to document counts as fully documented
+func percent(count int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Floor(float64(count)*1000/float64(total)) / 10
+}
+
+// moduleStats is the documentation coverage of a module
+// Coverage is the percentage of all of the counted items which are documented
+type moduleStats struct {
+	Folder              string     `json:"folder"`
+	VariablesDescribed  statsCount `json:"variablesDescribed"`
+	VariablesTyped      statsCount `json:"variablesTyped"`
+	OutputsDescribed    statsCount `json:"outputsDescribed"`
+	HasHeader           bool       `json:"hasHeader"`
+	HasReleases         bool       `json:"hasReleases"`
+	Coverage            float64    `json:"coverage"`
+	documented, counted int
+}
+
+// overallStats is the documentation coverage of all of the modules
+type overallStats struct {
+	Modules             int        `json:"modules"`
+	VariablesDescribed  statsCount `json:"variablesDescribed"`
+	VariablesTyped      statsCount `json:"variablesTyped"`
+	OutputsDescribed    statsCount `json:"outputsDescribed"`
+	ModulesWithHeaders  statsCount `json:"modulesWithHeaders"`
+	ModulesWithReleases statsCount `json:"modulesWithReleases"`
+	Coverage            float64    `json:"coverage"`
+}
+
+// coverageStats is the documentation coverage of each module and overall
+type coverageStats struct {
+	Modules []moduleStats `json:"modules"`
+	Overall overallStats  `json:"overall"`
+}
+
+// newCoverageStats counts the documented variables, outputs, headers and releases of the modules
+func newCoverageStats(details []CombinedModuleDetails) coverageStats {
+	stats := coverageStats{Modules: []moduleStats{}}
+	o := &stats.Overall
+	o.VariablesDescribed, o.VariablesTyped, o.OutputsDescribed, o.ModulesWithHeaders, o.ModulesWithReleases = noItems, noItems, noItems, noItems, noItems
+	documented, counted := 0, 0
+	for _, module := range details {
+		m := module.TFDetails
+		s := moduleStats{Folder: module.Folder, HasHeader: m.Title != "", VariablesDescribed: noItems, VariablesTyped: noItems, OutputsDescribed: noItems}
+		for _, c := range module.GitDetails {
+			s.HasReleases = s.HasReleases || c.Tag != ""
+		}
+		for _, v := range m.Variables {
+			s.VariablesDescribed.add(strings.TrimSpace(v.Desc) != "")
+			s.VariablesTyped.add(v.DataType != "")
+			o.VariablesDescribed.add(strings.TrimSpace(v.Desc) != "")
+			o.VariablesTyped.add(v.DataType != "")
+		}
+		for _, out := range m.Outputs {
+			s.OutputsDescribed.add(strings.TrimSpace(out.Desc) != "")
+			o.OutputsDescribed.add(strings.TrimSpace(out.Desc) != "")
+		}
+		o.ModulesWithHeaders.add(s.HasHeader)
+		o.ModulesWithReleases.add(s.HasReleases)
+		s.documented = s.VariablesDescribed.Count + s.VariablesTyped.Count + s.OutputsDescribed.Count
+		s.counted = s.VariablesDescribed.Total + s.VariablesTyped.Total + s.OutputsDescribed.Total + 2
+		if s.HasHeader {
+			s.documented++
+		}
+		if s.HasReleases {
+			s.documented++
+		}
+		s.Coverage = percent(s.documented, s.counted)
+		documented += s.documented
+		counted += s.counted
+		stats.Modules = append(stats.Modules, s)
+	}
+	o.Modules = len(details)
+	o.Coverage = percent(documented, counted)
+	return stats
+}
+
+// writeTable writes the coverage as a table, with a row for each module and a total
+func (stats coverageStats) writeTable(w io.Writer) {
+	t := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(t, "Module\tVariables described\tVariables typed\tOutputs described\tHeader\tReleased\tCoverage")
+	yesNo := map[bool]string{true: "yes", false: "no"}
+	for _, s := range stats.Modules {
+		fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\t%s\t%.1f%%\n", s.Folder, s.VariablesDescribed, s.VariablesTyped, s.OutputsDescribed, yesNo[s.HasHeader], yesNo[s.HasReleases], s.Coverage)
+	}
+	o := stats.Overall
+	fmt.Fprintf(t, "Total\t%s\t%s\t%s\t%s\t%s\t%.1f%%\n", o.VariablesDescribed, o.VariablesTyped, o.OutputsDescribed, o.ModulesWithHeaders, o.ModulesWithReleases, o.Coverage)
+	t.Flush()
+}
+
+// checkMinimum fails when the overall coverage is below the minimum percentage
+func (stats coverageStats) checkMinimum(minimum float64) error {
+	if stats.Overall.Coverage < minimum {
+		return fmt.Errorf("documentation coverage of %.1f%% is below the minimum of %.1f%%", stats.Overall.Coverage, minimum)
+	}
+	return nil
+}
+
+// coverageColour picks the colour of the coverage badge
+func coverageColour(coverage float64) string {
+	switch {
+	case coverage >= 90:
+		return "brightgreen"
+	case coverage >= 75:
+		return "green"
+	case coverage >= 60:
+		return "yellow"
+	case coverage >= 40:
+		return "orange"
+	}
+	return "red"
+}
+
+// badge is the overall coverage in the format of a shields.io endpoint badge, see https://shields.io/badges/endpoint-badge
+func (stats coverageStats) badge() ([]byte, error) {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"schemaVersion": 1,
+		"label":         "docs coverage",
+		"message":       fmt.Sprintf("%.0f%%", math.Floor(stats.Overall.Coverage)),
+		"color":         coverageColour(stats.Overall.Coverage),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// writeStats writes the coverage in a format, one of table, json or badge
+func writeStats(w io.Writer, stats coverageStats, format string) error {
+	var data []byte
+	var err error
+	switch format {
+	case "table":
+		stats.writeTable(w)
+		return nil
+	case "json":
+		data, err = json.MarshalIndent(stats, "", "  ")
+		data = append(data, '\n')
+	case "badge":
+		data, err = stats.badge()
+	default:
+		return fmt.Errorf("unknown stats format %q, expected table, json or badge", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
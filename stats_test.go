@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+	"github.com/richardjkendall/tf-auto-document/scangit"
+)
+
+func TestPercent(t *testing.T) {
+	cases := []struct {
+		count int
+		total int
+		want  float64
+	}{
+		{0, 0, 100},
+		{0, 5, 0},
+		{1, 1, 100},
+		{1, 8, 12.5},
+		{1, 3, 33.3},
+		// floored rather than rounded, so that nearly documented is never shown as fully documented
+		{2, 3, 66.6},
+		{999, 1000, 99.9},
+		{9999, 10000, 99.9},
+	}
+	for _, c := range cases {
+		if got := percent(c.count, c.total); got != c.want {
+			t.Errorf("percent(%d, %d) got %v, want %v", c.count, c.total, got, c.want)
+		}
+	}
+}
+
+// statsTestModules are a module which is partly documented and a module with nothing in it
+var statsTestModules = []CombinedModuleDetails{
+	{
+		Folder: "modules/vpc",
+		TFDetails: parser.ModuleDetails{
+			Title: "vpc",
+			Variables: []parser.VariableDetails{
+				{Name: "cidr", Desc: "The cidr.", DataType: "string"},
+				{Name: "name", Desc: "  "},
+			},
+			Outputs: []parser.OutputDetails{{Name: "id", Desc: "The id."}},
+		},
+		GitDetails: []scangit.GitCommit{{Message: "untagged"}, {Tag: "v1.0.0"}},
+	},
+	{Folder: "modules/empty"},
+}
+
+func TestNewCoverageStats(t *testing.T) {
+	stats := newCoverageStats(statsTestModules)
+	vpc, empty := stats.Modules[0], stats.Modules[1]
+	if vpc.VariablesDescribed != (statsCount{1, 2, 50}) || vpc.VariablesTyped != (statsCount{1, 2, 50}) || vpc.OutputsDescribed != (statsCount{1, 1, 100}) {
+		t.Errorf("got counts %+v", vpc)
+	}
+	// 5 of the 7 items, the 4 variable counts, the output, the header and the releases
+	if !vpc.HasHeader || !vpc.HasReleases || vpc.Coverage != 71.4 {
+		t.Errorf("got header %v, releases %v and coverage %v", vpc.HasHeader, vpc.HasReleases, vpc.Coverage)
+	}
+	// a module with no variables or outputs has all of them documented, but still needs a header and releases
+	if empty.VariablesDescribed != noItems || empty.OutputsDescribed != noItems || empty.Coverage != 0 {
+		t.Errorf("got %+v for the empty module", empty)
+	}
+	o := stats.Overall
+	if o.Modules != 2 || o.ModulesWithHeaders != (statsCount{1, 2, 50}) || o.ModulesWithReleases != (statsCount{1, 2, 50}) || o.Coverage != 55.5 {
+		t.Errorf("got overall %+v", o)
+	}
+
+	none := newCoverageStats(nil)
+	if none.Overall.Coverage != 100 || none.Overall.VariablesDescribed != noItems || len(none.Modules) != 0 {
+		t.Errorf("got %+v with no modules", none)
+	}
+}
+
+func TestCoverageColour(t *testing.T) {
+	cases := map[float64]string{
+		100:  "brightgreen",
+		90:   "brightgreen",
+		89.9: "green",
+		75:   "green",
+		74.9: "yellow",
+		60:   "yellow",
+		59.9: "orange",
+		40:   "orange",
+		39.9: "red",
+		0:    "red",
+	}
+	for coverage, want := range cases {
+		if got := coverageColour(coverage); got != want {
+			t.Errorf("coverageColour(%v) got %q, want %q", coverage, got, want)
+		}
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	stats := newCoverageStats(statsTestModules)
+	var table bytes.Buffer
+	if err := writeStats(&table, stats, "table"); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "Module") {
+		t.Fatalf("want a heading, a row for each module and a total, got\n%s", table.String())
+	}
+	if got := strings.Fields(lines[1]); strings.Join(got, " ") != "modules/vpc 1/2 (50%) 1/2 (50%) 1/1 (100%) yes yes 71.4%" {
+		t.Errorf("got row %q", got)
+	}
+	if got := strings.Fields(lines[2]); strings.Join(got, " ") != "modules/empty - - - no no 0.0%" {
+		t.Errorf("got row %q", got)
+	}
+	if !strings.HasPrefix(lines[3], "Total") || !strings.HasSuffix(lines[3], "55.5%") {
+		t.Errorf("got total %q", lines[3])
+	}
+
+	var j bytes.Buffer
+	if err := writeStats(&j, stats, "json"); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	var decoded coverageStats
+	if err := json.Unmarshal(j.Bytes(), &decoded); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if decoded.Overall.Coverage != 55.5 || decoded.Modules[0].Folder != "modules/vpc" || decoded.Modules[0].VariablesTyped.Percent != 50 {
+		t.Errorf("got %+v from the json", decoded)
+	}
+
+	var b bytes.Buffer
+	if err := writeStats(&b, stats, "badge"); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	var badge map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &badge); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if badge["schemaVersion"] != 1.0 || badge["message"] != "55%" || badge["color"] != "orange" || badge["label"] != "docs coverage" {
+		t.Errorf("got badge %v", badge)
+	}
+
+	if err := writeStats(&bytes.Buffer{}, stats, "csv"); err == nil {
+		t.Errorf("an unknown format should fail")
+	}
+}
+
+func TestCheckMinimum(t *testing.T) {
+	stats := newCoverageStats(statsTestModules)
+	for _, minimum := range []float64{0, 55, 55.5} {
+		if err := stats.checkMinimum(minimum); err != nil {
+			t.Errorf("minimum %v: %v", minimum, err)
+		}
+	}
+	err := stats.checkMinimum(55.6)
+	if err == nil || err.Error() != "documentation coverage of 55.5% is below the minimum of 55.6%" {
+		t.Errorf("got %v for a minimum above the coverage", err)
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+	"github.com/richardjkendall/tf-auto-document/scangit"
+	"github.com/richardjkendall/tf-auto-document/writer"
+)
+
+// moduleTemplateData is the data passed to the module README template, and for each module to the root README template
+type moduleTemplateData struct {
+	Folder          string
+	Module          parser.ModuleDetails
+	Commits         []scangit.GitCommit
+	Releases        []scangit.GitCommit
+	Owners          []string
+	SourceURL       string
+	Maintainer      bool
+	UnusedVariables []string
+	EmptyOutputs    []string
+	// Graph is the mermaid source of the graph of the module's dependencies, dependents and partners
+	Graph string
+	// UsedBy are the modules which depend on the module, UsedByConfigs the root configurations which call it
+	UsedBy        []string
+	UsedByConfigs []string
+	// Parent is the module this is a submodule of and Submodules the modules in its modules folder
+	Parent     *ModuleLink
+	Submodules []ModuleLink
+	// graph is the graph of all the modules, used to find the folders of the modules named in the README
+	graph moduleGraph
+	// headersOnly is set when only the modules with a header have a page, so that the others are not linked to
+	headersOnly bool
+}
+
+// PathTo is the path from the module's folder to the folder of another module, given by name or folder
+// modules which are not in the repository are assumed to be in a folder next to the module's own
+func (d moduleTemplateData) PathTo(name string) string {
+	folder, ok := d.graph.resolve(name)
+	if !ok {
+		return "../" + name
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(d.Folder), filepath.FromSlash(folder))
+	if err != nil {
+		return "../" + name
+	}
+	return filepath.ToSlash(rel)
+}
+
+// InRepo checks if another module, given by name or folder, is one of the modules in the repository, so that it can be linked to
+// modules without a header are not when only the modules with a header have a page
+func (d moduleTemplateData) InRepo(name string) bool {
+	folder, ok := d.graph.resolve(name)
+	if ok && d.headersOnly {
+		return d.graph.headers[folder]
+	}
+	return ok
+}
+
+// moduleGroup is the modules in a folder, for the index in the root README
+type moduleGroup struct {
+	Folder  string
+	Modules []moduleTemplateData
+}
+
+// rootTemplateData is the data passed to the root README template
+type rootTemplateData struct {
+	// Title and Intro are the heading of the root README and the paragraphs under it
+	Title   string
+	Intro   []string
+	Modules []moduleTemplateData
+	// Groups are the modules with a header, except submodules, grouped by the folder they are in
+	Groups []moduleGroup
+	// Graph is the mermaid source of the graph of how all of the modules relate to each other
+	Graph string
+}
+
+// newModuleTemplateData builds the data passed to the templates for a module, graph is the graph of all the modules
+func newModuleTemplateData(details CombinedModuleDetails, graph moduleGraph, opts readmeOptions) moduleTemplateData {
+	node := filepath.ToSlash(details.Folder)
+	d := moduleTemplateData{
+		Folder:          details.Folder,
+		Module:          details.TFDetails,
+		Commits:         details.GitDetails,
+		SourceURL:       details.SourceURL,
+		Maintainer:      opts.maintainer,
+		UnusedVariables: details.TFDetails.UnusedVariables(),
+		EmptyOutputs:    details.TFDetails.EmptyOutputs(),
+		Graph:           graph.neighbourhood(node).mermaid(node),
+		UsedByConfigs:   details.Configs,
+		Parent:          details.Parent,
+		Submodules:      details.Submodules,
+		graph:           graph,
+		headersOnly:     opts.headersOnly,
+	}
+	for _, dependent := range graph.dependents(node) {
+		d.UsedBy = append(d.UsedBy, graph.label(dependent))
+	}
+	for _, commit := range details.GitDetails {
+		if commit.Tag != "" {
+			d.Releases = append(d.Releases, commit)
+		}
+	}
+	d.Owners = append(d.Owners, details.TFDetails.Owners...)
+	return d
+}
+
+// title is the heading of the root README, which is also the title of the site and the docs overview
+func (opts readmeOptions) title() string {
+	if opts.rootTitle == "" {
+		return defaultRootTitle
+	}
+	return opts.rootTitle
+}
+
+// newRootTemplateData builds the data passed to the root template
+func newRootTemplateData(details []CombinedModuleDetails, opts readmeOptions) rootTemplateData {
+	graph := newModuleGraph(details)
+	data := rootTemplateData{Title: opts.title(), Intro: opts.rootIntro, Graph: graph.mermaid("")}
+	if len(data.Intro) == 0 {
+		data.Intro = defaultRootIntro
+	}
+	groups := make(map[string]int)
+	for _, module := range details {
+		d := newModuleTemplateData(module, graph, opts)
+		data.Modules = append(data.Modules, d)
+		// submodules are listed in the README of their parent rather than in the index
+		if module.TFDetails.Title == "" || module.Parent != nil {
+			continue
+		}
+		folder := path.Dir(module.Folder)
+		i, ok := groups[folder]
+		if !ok {
+			i = len(data.Groups)
+			groups[folder] = i
+			data.Groups = append(data.Groups, moduleGroup{Folder: folder})
+		}
+		data.Groups[i].Modules = append(data.Groups[i].Modules, d)
+	}
+	sort.Slice(data.Groups, func(i, j int) bool {
+		return data.Groups[i].Folder < data.Groups[j].Folder
+	})
+	return data
+}
+
+// templateFuncs are the helper functions which can be used in the templates, formatted by the renderer
+func templateFuncs(r writer.Renderer) template.FuncMap {
+	return template.FuncMap{
+		"h1": func(text string) string {
+			return r.Heading(1, text)
+		},
+		"h2": func(text string) string {
+			return r.Heading(2, text)
+		},
+		"h3": func(text string) string {
+			return r.Heading(3, text)
+		},
+		"p":       r.Paragraph,
+		"list":    r.ListStart,
+		"bullet":  r.Bullet,
+		"endList": r.ListEnd,
+		"quote":   r.Quote,
+		"table": func(headers ...string) string {
+			return r.TableHeader(headers)
+		},
+		"row": func(cells ...string) string {
+			return r.TableRow(cells)
+		},
+		"endTable": r.TableEnd,
+		"bold":     r.Bold,
+		"code":     r.Code,
+		"link":     r.Link,
+		"text":     r.Text,
+		"escape":   r.Escape,
+		"badge":    r.Badge,
+		"badges": func(m parser.ModuleDetails) string {
+			var badges []string
+			if m.Status != "" {
+				badges = append(badges, r.Badge("status", m.Status, statusColours[m.Status]))
+			}
+			if m.Team != "" {
+				badges = append(badges, r.Badge("team", m.Team, "blue"))
+			}
+			for _, t := range m.Tags {
+				badges = append(badges, r.Badge("tag", t, "lightgrey"))
+			}
+			return strings.Join(badges, " ")
+		},
+		"source": func(baseURL string, loc parser.SourceLocation) string {
+			return sourceLink(r, baseURL, loc)
+		},
+		"codeBlock": func(language string, source string) string {
+			if !strings.HasSuffix(source, "\n") {
+				source = source + "\n"
+			}
+			return r.CodeBlock(language, source)
+		},
+		"diagram":    r.Diagram,
+		"ext":        r.Extension,
+		"join":       strings.Join,
+		"trim":       strings.Trim,
+		"trimSuffix": strings.TrimSuffix,
+		"sentence": func(text string) string {
+			text = strings.TrimSpace(text)
+			if endsInPunctuation(text) {
+				return text
+			}
+			return text + "."
+		},
+		"oneLine": func(text string) string {
+			return strings.Join(strings.Fields(text), " ")
+		},
+	}
+}
+
+// loadTemplate parses the template in a file, or the default template if no file is given
+func loadTemplate(name string, file string, defaultTemplate string, r writer.Renderer) (*template.Template, error) {
+	text := defaultTemplate
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	t, err := template.New(name).Funcs(templateFuncs(r)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s template: %v", name, err)
+	}
+	return t, nil
+}
+
+// executeTemplate runs a template and returns the text it produced
+func executeTemplate(t *template.Template, data interface{}) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// moduleHeaderTemplate is the start of the built-in module README layout, with the title, badges, description and owners
+const moduleHeaderTemplate = `
+{{- h1 (text .Module.Title) -}}
+{{- if or .Module.Status .Module.Team .Module.Tags -}}
+	{{- p (badges .Module) -}}
+{{- end -}}
+{{- if eq .Module.Status "deprecated" -}}
+	{{- $notice := "this module is deprecated." -}}
+	{{- with .Module.Deprecated -}}
+		{{- $notice = text (sentence .) -}}
+	{{- end -}}
+	{{- with .Module.ReplacedBy -}}
+		{{- $notice = printf "%s Use %s instead." $notice (or (and ($.InRepo .) (link . (printf "%s/README%s" ($.PathTo .) ext))) (text .)) -}}
+	{{- end -}}
+	{{- quote (printf "%s %s" (bold "Deprecated:") $notice) -}}
+{{- end -}}
+{{- p (text .Module.Desc) -}}
+{{- with .Module.Owners -}}
+	{{- p (printf "Owners: %s" (text (join . ", "))) -}}
+{{- end -}}
+{{- with .Parent -}}
+	{{- p (printf "This is a submodule of %s." (or (and ($.InRepo .Folder) (link .Title (printf "%s/README%s" .Path ext))) (text .Title))) -}}
+{{- end -}}
+`
+
+// moduleSections are the sections of the built-in module README layout which can be hidden or reordered, in their default order
+var moduleSections = []struct {
+	name string
+	text string
+}{
+	{"depends", `
+{{- with .Module.Depends -}}
+	{{- h2 "Depends on" -}}
+	{{- list -}}
+	{{- range . }}{{ if $.InRepo . }}{{ bullet (link . (printf "%s/README%s" ($.PathTo .) ext)) }}{{ else }}{{ bullet (text .) }}{{ end }}{{ end -}}
+	{{- endList -}}
+	{{- p "" -}}
+{{- end -}}
+`},
+	{"partners", `
+{{- with .Module.Partners -}}
+	{{- h2 "Works with" -}}
+	{{- list -}}
+	{{- range . }}{{ if $.InRepo . }}{{ bullet (link . (printf "%s/README%s" ($.PathTo .) ext)) }}{{ else }}{{ bullet (text .) }}{{ end }}{{ end -}}
+	{{- endList -}}
+	{{- p "" -}}
+{{- end -}}
+`},
+	{"used-by", `
+{{- if or .UsedBy .UsedByConfigs -}}
+	{{- h2 "Used by" -}}
+	{{- list -}}
+	{{- range .UsedBy }}{{ if $.InRepo . }}{{ bullet (link . (printf "%s/README%s" ($.PathTo .) ext)) }}{{ else }}{{ bullet (text .) }}{{ end }}{{ end -}}
+	{{- range .UsedByConfigs }}{{ bullet (code .) }}{{ end -}}
+	{{- endList -}}
+	{{- p "" -}}
+{{- end -}}
+`},
+	{"graph", `
+{{- with .Graph -}}
+	{{- h2 "Dependency graph" -}}
+	{{- diagram . -}}
+{{- end -}}
+`},
+	{"submodules", `
+{{- with .Submodules -}}
+	{{- h2 "Submodules" -}}
+	{{- table "Module" "Description" -}}
+	{{- range . }}{{ row (or (and ($.InRepo .Folder) (link .Title (printf "%s/README%s" .Path ext))) (text .Title)) (text .Desc) }}{{ end -}}
+	{{- endTable -}}
+{{- end -}}
+`},
+	{"releases", `
+{{- h2 "Releases" -}}
+{{- if .Releases -}}
+	{{- table "Tag" "Message" "Commit" -}}
+	{{- range .Releases }}{{ row (text .Tag) (text (trim .Message "\r\n")) (code (printf "%.7s" .Hash)) }}{{ end -}}
+	{{- endTable -}}
+{{- else -}}
+	{{- p "There have been no releases yet for this module" -}}
+{{- end -}}
+`},
+	{"variables", `
+{{- h2 "Variables" -}}
+{{- table "Name" "Type" "Description" "Default Value" "Defined in" -}}
+{{- range .Module.Variables -}}
+	{{- row (code .Name) (or (and .DataType (code .DataType)) (code "not specified")) (text .Desc) (code .Def) (source $.SourceURL .Location) -}}
+{{- end -}}
+{{- endTable -}}
+{{- range .Module.Variables -}}
+	{{- if .Doc }}{{ h3 (code .Name) }}{{ p (text .Doc) }}{{ end -}}
+{{- end -}}
+`},
+	{"outputs", `
+{{- with .Module.Outputs -}}
+	{{- h2 "Outputs" -}}
+	{{- table "Name" "Description" "Defined in" -}}
+	{{- range . }}{{ row (text .Name) (text .Desc) (source $.SourceURL .Location) }}{{ end -}}
+	{{- endTable -}}
+	{{- range . -}}
+		{{- if .Doc }}{{ h3 (code .Name) }}{{ p (text .Doc) }}{{ end -}}
+	{{- end -}}
+{{- end -}}
+`},
+	{"locals", `
+{{- with .Module.Locals -}}
+	{{- h2 "Locals" -}}
+	{{- table "Name" "Value" "Defined in" -}}
+	{{- range . }}{{ row (code .Name) (code (oneLine .Expr)) (source $.SourceURL .Location) }}{{ end -}}
+	{{- endTable -}}
+	{{- range . -}}
+		{{- if .Doc }}{{ h3 (code .Name) }}{{ p (text .Doc) }}{{ end -}}
+	{{- end -}}
+{{- end -}}
+`},
+	{"resources", `
+{{- with .Module.Resources -}}
+	{{- h2 "Resources" -}}
+	{{- table "Resource" "Defined in" -}}
+	{{- range . -}}
+		{{- if eq .Mode "data" -}}
+			{{- row (code (printf "data.%s.%s" .Type .Name)) (source $.SourceURL .Location) -}}
+		{{- else -}}
+			{{- row (code (printf "%s.%s" .Type .Name)) (source $.SourceURL .Location) -}}
+		{{- end -}}
+	{{- end -}}
+	{{- endTable -}}
+{{- end -}}
+`},
+	{"examples", `
+{{- with .Module.Examples -}}
+	{{- h2 "Examples" -}}
+	{{- range $example := . -}}
+		{{- h3 (text .Name) -}}
+		{{- list -}}
+		{{- range .Files }}{{ bullet (link . (printf "%s%s/%s" $.SourceURL $example.Folder .)) }}{{ end -}}
+		{{- endList -}}
+		{{- p "" -}}
+		{{- with .Main }}{{ codeBlock "hcl" . }}{{ end -}}
+	{{- end -}}
+{{- end -}}
+`},
+	{"maintainer-notes", `
+{{- if and .Maintainer (or .UnusedVariables .EmptyOutputs) -}}
+	{{- h2 "Maintainer notes" -}}
+	{{- list -}}
+	{{- range .UnusedVariables }}{{ bullet (printf "variable %s is declared but never used" (code .)) }}{{ end -}}
+	{{- range .EmptyOutputs }}{{ bullet (printf "output %s does not refer to anything in the module" (code .)) }}{{ end -}}
+	{{- endList -}}
+	{{- p "" -}}
+{{- end -}}
+`},
+	{"header-location", `
+{{- if .Module.HeaderLoc.File -}}
+	{{- p (printf "Module header defined in %s" (source .SourceURL .Module.HeaderLoc)) -}}
+{{- end -}}
+`},
+}
+
+// sectionNames lists the names of the sections of the built-in module README layout in their default order
+func sectionNames() []string {
+	var names []string
+	for _, s := range moduleSections {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// defaultModuleTemplate builds the built-in layout of the module README used when no template is given
+// sections are the names of the sections to include in order, all of them in their default order if none are given
+func defaultModuleTemplate(sections []string) (string, error) {
+	if len(sections) == 0 {
+		sections = sectionNames()
+	}
+	text := moduleHeaderTemplate
+	for _, name := range sections {
+		found := false
+		for _, s := range moduleSections {
+			if s.name == strings.TrimSpace(name) {
+				text = text + s.text
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("unknown section %q, expected some of %s", strings.TrimSpace(name), strings.Join(sectionNames(), ", "))
+		}
+	}
+	return text, nil
+}
+
+// defaultRootTitle and defaultRootIntro are the heading of the root README and the paragraphs under it, when none are configured
+const defaultRootTitle = "Terraform Modules"
+
+var defaultRootIntro = []string{
+	"This is a collection of terraform modules",
+	"Click on the links to see the details of each of the modules",
+	"This documentation is auto-generated from the terraform files using tf-auto-document.",
+}
+
+// defaultRootTemplate is the layout of the root README used when no template is given
+const defaultRootTemplate = `
+{{- h1 (text .Title) -}}
+{{- range .Intro }}{{ p (text .) }}{{ end -}}
+{{- h2 "Modules" -}}
+{{- range .Groups -}}
+	{{- if gt (len $.Groups) 1 }}{{ h3 (code .Folder) }}{{ end -}}
+	{{- table "Module" "Description" "Status" "Team" "Owners" "Tags" "Link" -}}
+	{{- range .Modules -}}
+		{{- row (text .Module.Title) (text .Module.Desc) (text .Module.Status) (text .Module.Team) (text (join .Owners ", ")) (text (join .Module.Tags ", ")) (link "more details" (printf "%s/README%s" .Folder ext)) -}}
+	{{- end -}}
+	{{- endTable -}}
+{{- end -}}
+{{- with .Graph -}}
+	{{- h2 "Dependency graph" -}}
+	{{- diagram . -}}
+{{- end -}}
+`
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+	"github.com/richardjkendall/tf-auto-document/writer"
+)
+
+// testOptions are the options for the built-in layouts rendered in a format
+func testOptions(t *testing.T, format string) readmeOptions {
+	r, err := writer.NewRenderer(format)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	layout, err := defaultModuleTemplate(nil)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	opts := readmeOptions{renderer: r}
+	if opts.moduleTemplate, err = loadTemplate("module", "", layout, r); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if opts.rootTemplate, err = loadTemplate("root", "", defaultRootTemplate, r); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	return opts
+}
+
+func TestDeprecatedNotice(t *testing.T) {
+	cases := map[string]string{
+		"":                        "**Deprecated:** this module is deprecated.",
+		"Do not use":              "**Deprecated:** Do not use.",
+		"Do not use.":             "**Deprecated:** Do not use.",
+		"Do not use!":             "**Deprecated:** Do not use!",
+		"Why is this still here?": "**Deprecated:** Why is this still here?",
+	}
+	opts := testOptions(t, "markdown")
+	for deprecated, want := range cases {
+		details := CombinedModuleDetails{
+			Folder:    "modules/old",
+			TFDetails: parser.ModuleDetails{Title: "old", Status: parser.StatusDeprecated, Deprecated: deprecated},
+		}
+		text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(details, newModuleGraph(nil), opts))
+		if err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		if !strings.Contains(text, want) {
+			t.Errorf("deprecated %q: want %q in\n%s", deprecated, want, text)
+		}
+		if strings.Contains(text, "!.") || strings.Contains(text, "?.") || strings.Contains(text, "..") {
+			t.Errorf("deprecated %q: doubled punctuation in\n%s", deprecated, text)
+		}
+	}
+}
+
+func TestPathTo(t *testing.T) {
+	var details []CombinedModuleDetails
+	for _, folder := range []string{"modules/aws/vpc", "modules/aws/subnet", "modules/azure/vnet", "modules/aws/vpc/modules/endpoints", "modules/aws/dns", "platform/dns"} {
+		details = append(details, CombinedModuleDetails{Folder: folder, TFDetails: parser.ModuleDetails{Title: folder}})
+	}
+	graph := newModuleGraph(details)
+	cases := []struct {
+		from string
+		name string
+		want string
+	}{
+		{"modules/aws/vpc", "subnet", "../subnet"},
+		{"modules/aws/vpc", "vnet", "../../azure/vnet"},
+		{"modules/aws/vpc", "endpoints", "modules/endpoints"},
+		{"modules/aws/vpc/modules/endpoints", "vpc", "../.."},
+		{"modules/aws/vpc", "platform/dns", "../../../platform/dns"},
+		{"platform/dns", "modules/aws/dns", "../../modules/aws/dns"},
+		// modules which are not in the repository, or could be more than one module, are taken to be next to the module
+		{"modules/aws/vpc", "missing", "../missing"},
+		{"modules/aws/vpc", "dns", "../dns"},
+	}
+	for _, c := range cases {
+		d := newModuleTemplateData(CombinedModuleDetails{Folder: c.from}, graph, readmeOptions{})
+		if got := d.PathTo(c.name); got != c.want {
+			t.Errorf("PathTo(%q) from %s got %q, want %q", c.name, c.from, got, c.want)
+		}
+	}
+}
+
+func TestUnresolvedModulesAreNotLinked(t *testing.T) {
+	details := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{
+			Title:      "subnet",
+			Status:     parser.StatusDeprecated,
+			Depends:    []string{"vpc", "vpcc"},
+			Partners:   []string{"nat"},
+			ReplacedBy: "subnet2",
+		}},
+	}
+	opts := testOptions(t, "markdown")
+	text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(details[1], newModuleGraph(details), opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, want := range []string{"* [vpc](../vpc/README.md)\n", "* vpcc\n", "* nat\n", "Use subnet2 instead."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("want %q in\n%s", want, text)
+		}
+	}
+	for _, name := range []string{"vpcc", "nat", "subnet2"} {
+		if strings.Contains(text, "["+name+"]") {
+			t.Errorf("%s is not in the repository so should not be linked\n%s", name, text)
+		}
+	}
+}
+
+func TestRootGroupsLeaveOutSubmodules(t *testing.T) {
+	modules := []CombinedModuleDetails{
+		{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/aws/vpc/modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet"}},
+		{Folder: "modules/azure/vnet", TFDetails: parser.ModuleDetails{Title: "vnet"}},
+		{Folder: "modules/azure/empty"},
+	}
+	linkSubmodules(modules)
+	data := newRootTemplateData(modules, readmeOptions{})
+	var groups []string
+	for _, g := range data.Groups {
+		for _, m := range g.Modules {
+			groups = append(groups, g.Folder+": "+m.Folder)
+		}
+	}
+	want := []string{"modules/aws: modules/aws/vpc", "modules/azure: modules/azure/vnet"}
+	if strings.Join(groups, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got groups %q, want %q", groups, want)
+	}
+	if len(data.Modules) != len(modules) {
+		t.Errorf("every module should be in .Modules, got %d", len(data.Modules))
+	}
+}
+
+func TestHTMLBulletsShareList(t *testing.T) {
+	details := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/iam", TFDetails: parser.ModuleDetails{Title: "iam"}},
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc", "iam"}}},
+	}
+	opts := testOptions(t, "html")
+	text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(details[2], newModuleGraph(details), opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := "<h2>Depends on</h2>\n<ul>\n<li><a href=\"../vpc/README.html\">vpc</a></li>\n<li><a href=\"../iam/README.html\">iam</a></li>\n</ul>\n"
+	if !strings.Contains(text, want) {
+		t.Errorf("want %q in\n%s", want, text)
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	details := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{
+			Title:     "subnet",
+			Desc:      "Subnets.",
+			Team:      "network",
+			Owners:    []string{"alice"},
+			Depends:   []string{"vpc"},
+			Variables: []parser.VariableDetails{{Name: "cidr", DataType: "string", Desc: "the cidr block"}},
+		}},
+		{Folder: "modules/empty"},
+	}
+	files, err := createReadmes("repo", details, testOptions(t, "markdown"))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want the root README and one for each module", len(files))
+	}
+	root := files[0].GetBuf()
+	if !strings.HasPrefix(root, "Terraform Modules\n======\n") || !strings.Contains(root, "subnet | Subnets. |") || !strings.Contains(root, "(modules/subnet/README.md)") {
+		t.Errorf("root README should list the subnet module\n%s", root)
+	}
+	// the team has a column of its own, the owners are only people
+	if !strings.Contains(root, "| Team | Owners |") || !strings.Contains(root, "| network | alice |") {
+		t.Errorf("root README should show the team and owners in their own columns\n%s", root)
+	}
+	if strings.Contains(root, "modules/empty") {
+		t.Errorf("modules without a header should not be in the index\n%s", root)
+	}
+	module := files[1].GetBuf()
+	for _, want := range []string{"subnet\n======\n", "Depends on\n------\n\n* vpc\n", "There have been no releases yet for this module", "`cidr` | `string` | the cidr block", "Owners: alice\n"} {
+		if !strings.Contains(module, want) {
+			t.Errorf("want %q in\n%s", want, module)
+		}
+	}
+}
+
+func TestUserTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(dir)
+	templates := map[string]string{
+		"module.tmpl": "{{ h1 .Module.Title }}{{ p (printf \"Owned by %s\" (join .Owners \", \")) }}{{ range .Module.Variables }}{{ bullet (code .Name) }}{{ end }}",
+		"root.tmpl":   "{{ h1 \"Modules\" }}{{ range .Modules }}{{ bullet (link .Module.Title (printf \"%s/README.md\" .Folder)) }}{{ end }}",
+	}
+	for name, text := range templates {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+	}
+	opts := testOptions(t, "markdown")
+	if opts.moduleTemplate, err = loadTemplate("module", filepath.Join(dir, "module.tmpl"), "", opts.renderer); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if opts.rootTemplate, err = loadTemplate("root", filepath.Join(dir, "root.tmpl"), defaultRootTemplate, opts.renderer); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	details := []CombinedModuleDetails{{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{
+		Title:     "vpc",
+		Owners:    []string{"alice", "bob"},
+		Variables: []parser.VariableDetails{{Name: "cidr"}, {Name: "name"}},
+	}}}
+	files, err := createReadmes(dir, details, opts)
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	want := map[string]string{
+		filepath.Join(dir, "README.md"):                "Modules\n======\n\n* [vpc](modules/vpc/README.md)\n",
+		filepath.Join(dir, "modules/vpc", "README.md"): "vpc\n======\n\n\nOwned by alice, bob\n\n* `cidr`\n* `name`\n",
+	}
+	for _, f := range files {
+		if got := f.GetBuf(); got != want[f.FileName()] {
+			t.Errorf("%s got %q, want %q", f.FileName(), got, want[f.FileName()])
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "module.tmpl")
+	r := testOptions(t, "markdown").renderer
+
+	// a template which cannot be parsed, or uses a function which does not exist, is reported
+	for _, text := range []string{"{{ h1 .Module.Title ", "{{ shout .Module.Title }}"} {
+		if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+			t.Fatalf("Issue %q", err)
+		}
+		_, err := loadTemplate("module", file, "", r)
+		if err == nil || !strings.HasPrefix(err.Error(), "could not parse module template") {
+			t.Errorf("expected a parse error for %q, got %v", text, err)
+		}
+	}
+	if _, err := loadTemplate("module", filepath.Join(dir, "missing.tmpl"), "", r); err == nil {
+		t.Errorf("expected an error for a template file which does not exist")
+	}
+
+	// a template which refers to a field which does not exist fails when it is run
+	if err := ioutil.WriteFile(file, []byte("{{ .Module.Name }}"), 0644); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	opts := testOptions(t, "markdown")
+	if opts.moduleTemplate, err = loadTemplate("module", file, "", r); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	details := []CombinedModuleDetails{{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}}}
+	if _, err := createReadmes(dir, details, opts); err == nil {
+		t.Errorf("expected an error for a template which refers to a missing field")
+	}
+}
+
+func TestRootGroups(t *testing.T) {
+	details := []CombinedModuleDetails{
+		{Folder: "platform/dns", TFDetails: parser.ModuleDetails{Title: "dns"}},
+		{Folder: "modules/aws/vpc", TFDetails: parser.ModuleDetails{Title: "vpc"}},
+		{Folder: "modules/aws/subnet", TFDetails: parser.ModuleDetails{Title: "subnet"}},
+		{Folder: "modules/aws/empty"},
+	}
+	data := newRootTemplateData(details, readmeOptions{})
+	if len(data.Groups) != 2 || data.Groups[0].Folder != "modules/aws" || data.Groups[1].Folder != "platform" {
+		t.Fatalf("got groups %+v, want modules/aws and platform", data.Groups)
+	}
+	if len(data.Groups[0].Modules) != 2 || data.Groups[0].Modules[0].Module.Title != "vpc" {
+		t.Errorf("modules with a header should be in their group in order, got %+v", data.Groups[0].Modules)
+	}
+
+	opts := testOptions(t, "markdown")
+	text, err := executeTemplate(opts.rootTemplate, newRootTemplateData(details, opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	for _, want := range []string{"### `modules/aws`", "### `platform`", "(modules/aws/vpc/README.md)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("want %q in\n%s", want, text)
+		}
+	}
+	// there are no headings when all of the modules are in one folder
+	text, err = executeTemplate(opts.rootTemplate, newRootTemplateData(details[1:], opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	if strings.Contains(text, "###") {
+		t.Errorf("want no folder headings in\n%s", text)
+	}
+}
+
+func TestDefaultModuleTemplateSections(t *testing.T) {
+	layout, err := defaultModuleTemplate([]string{"variables", " depends"})
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	opts := testOptions(t, "markdown")
+	if opts.moduleTemplate, err = loadTemplate("module", "", layout, opts.renderer); err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	details := []CombinedModuleDetails{
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc", Desc: "A vpc."}},
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Desc: "Subnets.", Depends: []string{"vpc"}}},
+	}
+	text, err := executeTemplate(opts.moduleTemplate, newModuleTemplateData(details[1], newModuleGraph(details), opts))
+	if err != nil {
+		t.Fatalf("Issue %q", err)
+	}
+	// only the chosen sections are included, in the order they are given, after the title and description
+	variables, depends := strings.Index(text, "Variables\n"), strings.Index(text, "Depends on\n")
+	if !strings.HasPrefix(text, "subnet\n") || variables < 0 || depends < variables || strings.Contains(text, "Releases") {
+		t.Errorf("unexpected layout\n%s", text)
+	}
+	if _, err := defaultModuleTemplate([]string{"variables", "inputs"}); err == nil || !strings.Contains(err.Error(), `"inputs"`) {
+		t.Errorf("expected an error naming the unknown section, got %v", err)
+	}
+}
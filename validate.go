@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// validateDependencies checks that the modules named in depends, partners and replaced-by exist in the repository
+// that no two modules have the same folder name, so that names refer to one module,
+// and that the modules do not depend on each other in a cycle
+// returns a finding for each problem, at the header of the module it is in, without a severity
+func validateDependencies(details []CombinedModuleDetails, graph moduleGraph) []lintFinding {
+	var findings []lintFinding
+	modules := make(map[string]CombinedModuleDetails)
+	for _, module := range details {
+		modules[filepath.ToSlash(module.Folder)] = module
+	}
+	add := func(rule string, module CombinedModuleDetails, message string) {
+		f := finding(module, module.TFDetails.HeaderLoc, message)
+		f.rule = rule
+		findings = append(findings, f)
+	}
+	var names []string
+	for name := range graph.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		folders := graph.named[name]
+		for _, folder := range folders {
+			if len(folders) > 1 {
+				add("shared-name", modules[folder], fmt.Sprintf("modules %s are all called %s, use their folders to refer to them", strings.Join(folders, ", "), name))
+			}
+		}
+	}
+	for _, module := range details {
+		m := module.TFDetails
+		check := func(field string, targets []string) {
+			for _, t := range targets {
+				if _, ok := graph.resolve(t); ok {
+					continue
+				}
+				message := fmt.Sprintf("%s %s is not a module in the repository", field, t)
+				if folders := graph.named[t]; len(folders) > 1 {
+					message = fmt.Sprintf("%s %s could be any of %s, use the folder of the module", field, t, strings.Join(folders, ", "))
+				} else if suggestion := closestName(t, names); suggestion != "" {
+					message = message + fmt.Sprintf(", did you mean %s?", suggestion)
+				}
+				add("unknown-module", module, message)
+			}
+		}
+		check("depends on", m.Depends)
+		check("works with", m.Partners)
+		if m.ReplacedBy != "" {
+			check("is replaced by", []string{m.ReplacedBy})
+		}
+	}
+	for _, cycle := range dependencyCycles(graph) {
+		var labels []string
+		for _, node := range cycle {
+			labels = append(labels, graph.label(node))
+		}
+		add("dependency-cycle", modules[cycle[0]], "dependency cycle: "+strings.Join(labels, " -> "))
+	}
+	return findings
+}
+
+// closestName finds the name most like a misspelt one, returns an empty string if none are close enough
+func closestName(name string, names []string) string {
+	best := ""
+	bestDistance := len(name)/3 + 1
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+		if d := editDistance(name, n); d <= bestDistance && (best == "" || d < editDistance(name, best)) {
+			best = n
+		}
+	}
+	return best
+}
+
+// editDistance counts the characters which have to be added, removed or changed to turn one string into another
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// min3 is the smallest of three numbers
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// dependencyCycles finds the groups of modules which depend on each other, using depends and module blocks
+// each cycle is given as the path from its first module, in name order, back to itself
+func dependencyCycles(graph moduleGraph) [][]string {
+	next := make(map[string][]string)
+	for _, e := range sortedEdges(graph.dependsAndCalls()) {
+		next[e[0]] = append(next[e[0]], e[1])
+	}
+
+	// find the strongly connected components with tarjan's algorithm
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, m := range next[n] {
+			if _, seen := index[m]; !seen {
+				visit(m)
+				if low[m] < low[n] {
+					low[n] = low[m]
+				}
+			} else if onStack[m] && index[m] < low[n] {
+				low[n] = index[m]
+			}
+		}
+		if low[n] == index[n] {
+			var component []string
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				component = append(component, m)
+				if m == n {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	for _, n := range graph.nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+
+	var cycles [][]string
+	for _, component := range components {
+		if len(component) == 1 && !containsName(next[component[0]], component[0]) {
+			continue
+		}
+		sort.Strings(component)
+		in := make(map[string]bool)
+		for _, n := range component {
+			in[n] = true
+		}
+		cycles = append(cycles, shortestCycle(component[0], next, in))
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// shortestCycle finds the shortest path from a module back to itself through the modules in a component
+func shortestCycle(start string, next map[string][]string, in map[string]bool) []string {
+	from := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range next[n] {
+			if !in[m] {
+				continue
+			}
+			if m == start {
+				path := []string{start}
+				for p := n; p != start; p = from[p] {
+					path = append([]string{p}, path...)
+				}
+				return append([]string{start}, path...)
+			}
+			if _, seen := from[m]; !seen {
+				from[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return []string{start}
+}
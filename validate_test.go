@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/richardjkendall/tf-auto-document/parser"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"vpc", "vpc", 0},
+		{"", "vpc", 3},
+		{"subnte", "subnet", 2},
+		{"subne", "subnet", 1},
+		{"kitten", "sitting", 3},
+		{"vpç", "vpc", 1},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) got %d, want %d", c.a, c.b, got, c.want)
+		}
+		if got := editDistance(c.b, c.a); got != c.want {
+			t.Errorf("editDistance(%q, %q) got %d, want %d", c.b, c.a, got, c.want)
+		}
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	names := []string{"dns", "subnet", "vpc", "vpc-peering"}
+	cases := map[string]string{
+		"subnte":     "subnet",
+		"VPC":        "vpc",
+		"vcp":        "vpc",
+		"vpc-peerin": "vpc-peering",
+		"iam":        "",
+		"database":   "",
+	}
+	for name, want := range cases {
+		if got := closestName(name, names); got != want {
+			t.Errorf("closestName(%q) got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// dependsGraph builds the graph of modules with the depends given, as module name to the names it depends on
+func dependsGraph(depends map[string][]string) moduleGraph {
+	var details []CombinedModuleDetails
+	for name, d := range depends {
+		details = append(details, CombinedModuleDetails{Folder: name, TFDetails: parser.ModuleDetails{Title: name, Depends: d}})
+	}
+	return newModuleGraph(details)
+}
+
+func TestDependencyCycles(t *testing.T) {
+	cases := []struct {
+		name    string
+		depends map[string][]string
+		want    [][]string
+	}{
+		{"no cycle", map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}, nil},
+		{"self loop", map[string][]string{"a": {"a"}, "b": {"a"}}, [][]string{{"a", "a"}}},
+		{"three modules", map[string][]string{"c": {"a"}, "a": {"b"}, "b": {"c"}}, [][]string{{"a", "b", "c", "a"}}},
+		{
+			"two components",
+			map[string][]string{"a": {"b"}, "b": {"a", "c"}, "c": {"d"}, "d": {"e"}, "e": {"c"}, "f": {"a"}},
+			[][]string{{"a", "b", "a"}, {"c", "d", "e", "c"}},
+		},
+		{
+			// the shortest way back is given when a component has more than one cycle
+			"shortest cycle",
+			map[string][]string{"a": {"b", "d"}, "b": {"c"}, "c": {"a"}, "d": {"a"}},
+			[][]string{{"a", "d", "a"}},
+		},
+	}
+	for _, c := range cases {
+		if got := dependencyCycles(dependsGraph(c.depends)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestShortestCycle(t *testing.T) {
+	next := map[string][]string{"a": {"x", "b"}, "b": {"c", "a"}, "c": {"a"}, "x": {"a"}}
+	if got := shortestCycle("a", next, map[string]bool{"a": true, "b": true, "c": true, "x": true}); !reflect.DeepEqual(got, []string{"a", "x", "a"}) {
+		t.Errorf("got %q", got)
+	}
+	// the path can only go through the modules in the component
+	if got := shortestCycle("a", next, map[string]bool{"a": true, "c": true, "b": true}); !reflect.DeepEqual(got, []string{"a", "b", "a"}) {
+		t.Errorf("got %q", got)
+	}
+	if got := shortestCycle("a", next, map[string]bool{"a": true, "c": true}); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+// findingLines formats findings as the text report does, without the severity
+func findingLines(findings []lintFinding) []string {
+	var lines []string
+	for _, f := range findings {
+		where := f.path()
+		if f.line > 0 {
+			where = fmt.Sprintf("%s:%d", where, f.line)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s (%s)", where, f.message, f.rule))
+	}
+	return lines
+}
+
+func TestValidateDependencies(t *testing.T) {
+	header := parser.SourceLocation{File: "main.tf", StartLine: 1, EndLine: 5}
+	details := []CombinedModuleDetails{
+		{Folder: "modules/subnet", TFDetails: parser.ModuleDetails{Title: "subnet", Depends: []string{"vpc"}, Partners: []string{"dsn"}, HeaderLoc: header}},
+		{Folder: "modules/vpc", TFDetails: parser.ModuleDetails{Title: "vpc", Depends: []string{"subnet"}, ReplacedBy: "vpc2"}},
+		{Folder: "modules/dns", TFDetails: parser.ModuleDetails{Title: "dns", Depends: []string{"dns"}, HeaderLoc: header}},
+	}
+	want := []string{
+		"modules/subnet/main.tf:1: works with dsn is not a module in the repository, did you mean dns? (unknown-module)",
+		"modules/vpc: is replaced by vpc2 is not a module in the repository, did you mean vpc? (unknown-module)",
+		"modules/dns/main.tf:1: dependency cycle: dns -> dns (dependency-cycle)",
+		"modules/subnet/main.tf:1: dependency cycle: subnet -> vpc -> subnet (dependency-cycle)",
+	}
+	if got := findingLines(validateDependencies(details, newModuleGraph(details))); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
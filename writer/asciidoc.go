@@ -0,0 +1,121 @@
+package writer
+
+import (
+	"strings"
+)
+
+// AsciiDoc renders documents as AsciiDoc, for example to be included in an Antora site
+type AsciiDoc struct{}
+
+// Heading formats a heading, level 1 is the document title
+func (AsciiDoc) Heading(level int, text string) string {
+	return strings.Repeat("=", level) + " " + text + "\n\n"
+}
+
+// Paragraph formats a block of text
+func (AsciiDoc) Paragraph(text string) string {
+	if text == "" {
+		return "\n"
+	}
+	return text + "\n\n"
+}
+
+// ListStart starts a list of bullet points, which needs no markup
+func (AsciiDoc) ListStart() string {
+	return ""
+}
+
+// Bullet formats a bullet point
+func (AsciiDoc) Bullet(text string) string {
+	return "* " + text + "\n"
+}
+
+// ListEnd finishes a list of bullet points, which needs no markup
+func (AsciiDoc) ListEnd() string {
+	return ""
+}
+
+// Quote formats a block quote
+func (AsciiDoc) Quote(text string) string {
+	return "____\n" + text + "\n____\n\n"
+}
+
+// TableHeader starts a table with a row of headers, the number of headers sets the number of columns
+func (a AsciiDoc) TableHeader(headers []string) string {
+	return "[options=\"header\"]\n|===\n" + a.TableRow(headers)
+}
+
+// TableRow formats a row of a table, pipes in the cells are escaped and line breaks are kept
+func (AsciiDoc) TableRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.Replace(strings.Trim(cell, "\r\n"), "|", "\\|", -1)
+		escaped[i] = strings.Replace(cell, "\n", " +\n", -1)
+	}
+	return "|" + strings.Join(escaped, " |") + "\n"
+}
+
+// TableEnd finishes a table
+func (AsciiDoc) TableEnd() string {
+	return "|===\n\n"
+}
+
+// Link formats a link
+func (AsciiDoc) Link(title string, url string) string {
+	return "link:" + strings.Replace(url, " ", "%20", -1) + "[" + strings.Replace(title, "]", "\\]", -1) + "]"
+}
+
+// Code formats inline code, as a literal so that it is not formatted
+func (a AsciiDoc) Code(code string) string {
+	if code == "" {
+		return ""
+	}
+	return "`" + a.Escape(code) + "`"
+}
+
+// Bold formats bold text
+func (AsciiDoc) Bold(text string) string {
+	return "*" + text + "*"
+}
+
+// Badge formats a shields.io badge
+func (AsciiDoc) Badge(label string, message string, colour string) string {
+	return "image:" + badgeURL(label, message, colour) + "[" + strings.Replace(label+": "+message, "]", "\\]", -1) + "]"
+}
+
+// Text formats plain text, which is left as it is
+func (AsciiDoc) Text(text string) string {
+	return text
+}
+
+// Escape formats plain text so that it is shown exactly as written, using an inline passthrough
+// text which would end the +...+ passthrough early uses the pass macro instead, which only needs ] escaped
+func (AsciiDoc) Escape(text string) string {
+	if text == "" {
+		return ""
+	}
+	if strings.Contains(text, "+") || strings.TrimSpace(text) != text {
+		return "pass:c[" + strings.Replace(text, "]", "\\]", -1) + "]"
+	}
+	return "+" + text + "+"
+}
+
+// Diagram formats a mermaid diagram as a block drawn by asciidoctor-diagram
+func (AsciiDoc) Diagram(source string) string {
+	return "[mermaid]\n....\n" + source + "....\n\n"
+}
+
+// CodeBlock formats source code as a listing block
+func (AsciiDoc) CodeBlock(language string, source string) string {
+	return "[source," + language + "]\n----\n" + source + "----\n\n"
+}
+
+// Comment formats a line comment
+func (AsciiDoc) Comment(text string) string {
+	return "// " + text
+}
+
+// Extension is the file extension used for AsciiDoc documents
+func (AsciiDoc) Extension() string {
+	return ".adoc"
+}
@@ -0,0 +1,111 @@
+package writer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HTML renders documents as fragments of html
+type HTML struct{}
+
+// Heading formats a heading
+func (HTML) Heading(level int, text string) string {
+	return fmt.Sprintf("<h%d>%s</h%d>\n", level, text, level)
+}
+
+// Paragraph formats a block of text
+func (HTML) Paragraph(text string) string {
+	if text == "" {
+		return "\n"
+	}
+	return "<p>" + text + "</p>\n"
+}
+
+// ListStart starts a list of bullet points
+func (HTML) ListStart() string {
+	return "<ul>\n"
+}
+
+// Bullet formats a bullet point
+func (HTML) Bullet(text string) string {
+	return "<li>" + text + "</li>\n"
+}
+
+// ListEnd finishes a list of bullet points
+func (HTML) ListEnd() string {
+	return "</ul>\n"
+}
+
+// Quote formats a block quote
+func (HTML) Quote(text string) string {
+	return "<blockquote>" + text + "</blockquote>\n"
+}
+
+// TableHeader starts a table with a row of headers
+func (HTML) TableHeader(headers []string) string {
+	return "<table>\n<tr><th>" + strings.Join(headers, "</th><th>") + "</th></tr>\n"
+}
+
+// TableRow formats a row of a table
+func (HTML) TableRow(cells []string) string {
+	return "<tr><td>" + strings.Join(cells, "</td><td>") + "</td></tr>\n"
+}
+
+// TableEnd finishes a table
+func (HTML) TableEnd() string {
+	return "</table>\n"
+}
+
+// Link formats a link
+func (HTML) Link(title string, url string) string {
+	return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(title) + "</a>"
+}
+
+// Code formats inline code
+func (HTML) Code(code string) string {
+	if code == "" {
+		return ""
+	}
+	return "<code>" + html.EscapeString(code) + "</code>"
+}
+
+// Bold formats bold text
+func (HTML) Bold(text string) string {
+	return "<strong>" + text + "</strong>"
+}
+
+// Badge formats a shields.io badge
+func (HTML) Badge(label string, message string, colour string) string {
+	return `<img src="` + html.EscapeString(badgeURL(label, message, colour)) + `" alt="` + html.EscapeString(label+": "+message) + `">`
+}
+
+// Text formats plain text, with line breaks kept
+func (HTML) Text(text string) string {
+	return strings.Replace(html.EscapeString(text), "\n", "<br>\n", -1)
+}
+
+// Escape formats plain text so that it is shown exactly as written
+func (HTML) Escape(text string) string {
+	return html.EscapeString(text)
+}
+
+// Diagram formats a mermaid diagram as the element drawn by mermaid.js
+func (HTML) Diagram(source string) string {
+	return "<pre class=\"mermaid\">\n" + html.EscapeString(source) + "</pre>\n"
+}
+
+// CodeBlock formats source code as a pre element, with the language class used by highlight.js and prism
+func (HTML) CodeBlock(language string, source string) string {
+	return "<pre><code class=\"language-" + html.EscapeString(language) + "\">" + html.EscapeString(source) + "</code></pre>\n"
+}
+
+// Comment formats an html comment
+func (HTML) Comment(text string) string {
+	return "<!-- " + text + " -->"
+}
+
+// Extension is the file extension used for html documents
+func (HTML) Extension() string {
+	return ".html"
+}
@@ -0,0 +1,123 @@
+package writer
+
+import (
+	"strings"
+)
+
+// Markdown renders documents as GitHub flavoured markdown
+type Markdown struct{}
+
+// Heading formats a heading, levels 1 and 2 are underlined
+func (Markdown) Heading(level int, text string) string {
+	switch level {
+	case 1:
+		return text + "\n======\n\n"
+	case 2:
+		return text + "\n------\n\n"
+	}
+	return strings.Repeat("#", level) + " " + text + "\n\n"
+}
+
+// Paragraph formats a block of text
+func (Markdown) Paragraph(text string) string {
+	return "\n" + text + "\n\n"
+}
+
+// ListStart starts a list of bullet points, which needs no markup
+func (Markdown) ListStart() string {
+	return ""
+}
+
+// Bullet formats a bullet point
+func (Markdown) Bullet(text string) string {
+	return "* " + text + "\n"
+}
+
+// ListEnd finishes a list of bullet points, which needs no markup
+func (Markdown) ListEnd() string {
+	return ""
+}
+
+// Quote formats a block quote
+func (Markdown) Quote(text string) string {
+	return "> " + text + "\n\n"
+}
+
+// TableHeader starts a table with a row of headers
+func (Markdown) TableHeader(headers []string) string {
+	cells := make([]string, len(headers))
+	hyphens := make([]string, len(headers))
+	for i, header := range headers {
+		cells[i] = escapeCell(header)
+		hyphens[i] = "---"
+	}
+	return "|" + strings.Join(cells, " | ") + "|\n" + strings.Join(hyphens, " | ") + "\n"
+}
+
+// TableRow formats a row of a table
+func (Markdown) TableRow(row []string) string {
+	cells := make([]string, len(row))
+	for i, cell := range row {
+		cells[i] = escapeCell(cell)
+	}
+	return strings.Join(cells, " | ") + "\n"
+}
+
+// TableEnd finishes a table
+func (Markdown) TableEnd() string {
+	return "\n"
+}
+
+// Link formats a link
+func (Markdown) Link(title string, url string) string {
+	return MakeLink(title, url)
+}
+
+// Code formats inline code
+func (Markdown) Code(code string) string {
+	return InlineCode(code)
+}
+
+// Bold formats bold text
+func (Markdown) Bold(text string) string {
+	return Bold(text)
+}
+
+// Badge formats a shields.io badge
+func (Markdown) Badge(label string, message string, colour string) string {
+	return MakeBadge(label, message, colour)
+}
+
+// Text formats plain text, which is left as it is so descriptions can use markdown
+func (Markdown) Text(text string) string {
+	return text
+}
+
+// Escape formats plain text so that it is shown exactly as written
+func (Markdown) Escape(text string) string {
+	return Escape(text)
+}
+
+// Comment formats an html comment
+func (Markdown) Comment(text string) string {
+	return "<!-- " + text + " -->"
+}
+
+// Diagram formats a mermaid diagram as a fenced code block, which GitHub and GitLab draw
+func (Markdown) Diagram(source string) string {
+	return "```mermaid\n" + source + "```\n\n"
+}
+
+// CodeBlock formats source code as a fenced code block, the fence is longer than any run of backticks in the source
+func (Markdown) CodeBlock(language string, source string) string {
+	fence := "```"
+	for strings.Contains(source, fence) {
+		fence = fence + "`"
+	}
+	return fence + language + "\n" + source + fence + "\n\n"
+}
+
+// Extension is the file extension used for markdown documents
+func (Markdown) Extension() string {
+	return ".md"
+}
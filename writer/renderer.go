@@ -0,0 +1,74 @@
+package writer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Renderer formats the parts of a document for one output format
+// text given to the methods is assumed to be formatted already, apart from link titles, use Text to format plain text
+type Renderer interface {
+	// Heading formats a heading, level 1 is the title of the document
+	Heading(level int, text string) string
+	// Paragraph formats a block of text, an empty paragraph is used to add space
+	Paragraph(text string) string
+	// ListStart starts a list of bullet points
+	ListStart() string
+	// Bullet formats a bullet point
+	Bullet(text string) string
+	// ListEnd finishes a list of bullet points
+	ListEnd() string
+	// Quote formats a block quote
+	Quote(text string) string
+	// TableHeader starts a table with a row of headers
+	TableHeader(headers []string) string
+	// TableRow formats a row of a table
+	TableRow(cells []string) string
+	// TableEnd finishes a table
+	TableEnd() string
+	// Link formats a link, the title is plain text
+	Link(title string, url string) string
+	// Code formats inline code
+	Code(code string) string
+	// Bold formats bold text
+	Bold(text string) string
+	// Badge formats a shields.io badge
+	Badge(label string, message string, colour string) string
+	// Text formats plain text which may contain the format's own markup, like a description
+	Text(text string) string
+	// Escape formats plain text so that it is shown exactly as written
+	Escape(text string) string
+	// Diagram formats a mermaid diagram, given as the mermaid source ending in a newline
+	Diagram(source string) string
+	// CodeBlock formats a block of source code in a language, given as the source ending in a newline
+	CodeBlock(language string, source string) string
+	// Comment formats a comment which is not shown in the rendered document
+	Comment(text string) string
+	// Extension is the file extension used for documents in this format
+	Extension() string
+}
+
+// Formats lists the names of the output formats which have a renderer
+var Formats = []string{"markdown", "html", "asciidoc", "rst"}
+
+// NewRenderer gets the renderer for an output format
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case "markdown", "md":
+		return Markdown{}, nil
+	case "html":
+		return HTML{}, nil
+	case "asciidoc", "adoc":
+		return AsciiDoc{}, nil
+	case "rst", "restructuredtext":
+		return ReST{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// badgeURL makes the url of a shields.io badge
+func badgeURL(label string, message string, colour string) string {
+	escape := strings.NewReplacer("-", "--", "_", "__", " ", "_")
+	return "https://img.shields.io/badge/" + url.PathEscape(escape.Replace(label)) + "-" + url.PathEscape(escape.Replace(message)) + "-" + colour
+}
@@ -0,0 +1,115 @@
+package writer
+
+import (
+	"testing"
+)
+
+// renderTable writes a small document using each part of a renderer
+func renderTable(r Renderer) string {
+	w := NewWithRenderer("", r)
+	w.H2("Variables")
+	w.Table([]string{"Name", "Description"}, [][]string{
+		[]string{r.Code("a"), r.Text("x < y | z")},
+	})
+	w.ListStart()
+	w.Bullet(r.Link("vpc", "../vpc/README"+r.Extension()))
+	w.Bullet(r.Code("b"))
+	w.ListEnd()
+	return w.GetBuf()
+}
+
+func TestRenderers(t *testing.T) {
+	cases := map[string]string{
+		"markdown": "Variables\n------\n\n|Name | Description|\n--- | ---\n`a` | x < y \\| z\n\n* [vpc](../vpc/README.md)\n* `b`\n",
+		"html":     "<h2>Variables</h2>\n<table>\n<tr><th>Name</th><th>Description</th></tr>\n<tr><td><code>a</code></td><td>x &lt; y | z</td></tr>\n</table>\n<ul>\n<li><a href=\"../vpc/README.html\">vpc</a></li>\n<li><code>b</code></li>\n</ul>\n",
+		"asciidoc": "== Variables\n\n[options=\"header\"]\n|===\n|Name |Description\n|`+a+` |x < y \\| z\n|===\n\n* link:../vpc/README.adoc[vpc]\n* `+b+`\n",
+		"rst":      "Variables\n---------\n\n.. list-table::\n   :header-rows: 1\n\n   * - Name\n     - Description\n   * - ``a``\n     - x < y | z\n\n* `vpc <../vpc/README.rst>`__\n* ``b``\n",
+	}
+	for format, want := range cases {
+		r, err := NewRenderer(format)
+		if err != nil {
+			t.Errorf("Issue %q", err)
+			continue
+		}
+		if got := renderTable(r); got != want {
+			t.Errorf("%s got %q, want %q", format, got, want)
+		}
+	}
+	if _, err := NewRenderer("pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestDiagrams(t *testing.T) {
+	source := "flowchart LR\n  a --> b\n"
+	cases := map[string]string{
+		"markdown": "```mermaid\nflowchart LR\n  a --> b\n```\n\n",
+		"html":     "<pre class=\"mermaid\">\nflowchart LR\n  a --&gt; b\n</pre>\n",
+		"asciidoc": "[mermaid]\n....\nflowchart LR\n  a --> b\n....\n\n",
+		"rst":      ".. mermaid::\n\n   flowchart LR\n     a --> b\n\n",
+	}
+	for format, want := range cases {
+		r, err := NewRenderer(format)
+		if err != nil {
+			t.Errorf("Issue %q", err)
+			continue
+		}
+		if got := r.Diagram(source); got != want {
+			t.Errorf("%s got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestCodeBlocks(t *testing.T) {
+	source := "module \"vpc\" {\n  source = \"../..\"\n}\n"
+	cases := map[string]string{
+		"markdown": "```hcl\nmodule \"vpc\" {\n  source = \"../..\"\n}\n```\n\n",
+		"html":     "<pre><code class=\"language-hcl\">module &#34;vpc&#34; {\n  source = &#34;../..&#34;\n}\n</code></pre>\n",
+		"asciidoc": "[source,hcl]\n----\nmodule \"vpc\" {\n  source = \"../..\"\n}\n----\n\n",
+		"rst":      ".. code-block:: hcl\n\n   module \"vpc\" {\n     source = \"../..\"\n   }\n\n",
+	}
+	for format, want := range cases {
+		r, err := NewRenderer(format)
+		if err != nil {
+			t.Errorf("Issue %q", err)
+			continue
+		}
+		if got := r.CodeBlock("hcl", source); got != want {
+			t.Errorf("%s got %q, want %q", format, got, want)
+		}
+	}
+	// a fence in the source needs a longer fence around it
+	r, _ := NewRenderer("markdown")
+	if got, want := r.CodeBlock("md", "```\nx\n```\n"), "````md\n```\nx\n```\n````\n\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCodeEscaping(t *testing.T) {
+	cases := []struct {
+		format string
+		code   string
+		want   string
+	}{
+		{"rst", "a``b", ":literal:`a\\`\\`b`"},
+		{"rst", "`x` \\ y", ":literal:`\\`x\\` \\\\ y`"},
+		{"rst", "a\\b", "``a\\b``"},
+		{"asciidoc", "a+b", "`pass:c[a+b]`"},
+		{"asciidoc", "x[0] ++ y", "`pass:c[x[0\\] ++ y]`"},
+		{"asciidoc", "map(string)", "`+map(string)+`"},
+	}
+	for _, c := range cases {
+		r, err := NewRenderer(c.format)
+		if err != nil {
+			t.Errorf("Issue %q", err)
+			continue
+		}
+		if got := r.Code(c.code); got != c.want {
+			t.Errorf("%s Code(%q) got %q, want %q", c.format, c.code, got, c.want)
+		}
+	}
+	r, _ := NewRenderer("asciidoc")
+	if got, want := r.Escape("1 + 1 = 2"), "pass:c[1 + 1 = 2]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
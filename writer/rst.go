@@ -0,0 +1,134 @@
+package writer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ReST renders documents as reStructuredText, for example to be included in a Sphinx site
+type ReST struct{}
+
+// characters used to underline each level of heading
+var rstHeadingChars = []string{"=", "-", "~", "^"}
+
+// rstEscaper escapes the characters which have a special meaning in reStructuredText
+var rstEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "|", "\\|")
+
+// Heading formats a heading, which is underlined by a line at least as long as the text
+func (ReST) Heading(level int, text string) string {
+	if level > len(rstHeadingChars) {
+		level = len(rstHeadingChars)
+	}
+	return text + "\n" + strings.Repeat(rstHeadingChars[level-1], utf8.RuneCountInString(text)) + "\n\n"
+}
+
+// Paragraph formats a block of text
+func (ReST) Paragraph(text string) string {
+	if text == "" {
+		return "\n"
+	}
+	return text + "\n\n"
+}
+
+// ListStart starts a list of bullet points, which needs no markup
+func (ReST) ListStart() string {
+	return ""
+}
+
+// Bullet formats a bullet point
+func (ReST) Bullet(text string) string {
+	return "* " + text + "\n"
+}
+
+// ListEnd finishes a list of bullet points, which needs no markup
+func (ReST) ListEnd() string {
+	return ""
+}
+
+// Quote formats a block quote, which is indented
+func (ReST) Quote(text string) string {
+	return "    " + strings.Replace(text, "\n", "\n    ", -1) + "\n\n"
+}
+
+// TableHeader starts a list table with a row of headers
+func (r ReST) TableHeader(headers []string) string {
+	return ".. list-table::\n   :header-rows: 1\n\n" + r.TableRow(headers)
+}
+
+// TableRow formats a row of a list table, line breaks in the cells are replaced by spaces
+func (ReST) TableRow(cells []string) string {
+	var row string
+	for i, cell := range cells {
+		marker := "     - "
+		if i == 0 {
+			marker = "   * - "
+		}
+		row = row + strings.TrimRight(marker+strings.Join(strings.Fields(cell), " "), " ") + "\n"
+	}
+	return row
+}
+
+// TableEnd finishes a table
+func (ReST) TableEnd() string {
+	return "\n"
+}
+
+// Link formats an anonymous link
+func (ReST) Link(title string, url string) string {
+	return "`" + rstEscaper.Replace(title) + " <" + url + ">`__"
+}
+
+// rstLiteralEscaper escapes the characters which would end the text of a role early
+var rstLiteralEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// Code formats inline code as an inline literal, which cannot hold backticks
+// so code with backticks uses the literal role instead, where they can be escaped
+func (ReST) Code(code string) string {
+	if code == "" {
+		return ""
+	}
+	if strings.Contains(code, "`") {
+		return ":literal:`" + rstLiteralEscaper.Replace(code) + "`"
+	}
+	return "``" + code + "``"
+}
+
+// Bold formats bold text
+func (ReST) Bold(text string) string {
+	return "**" + text + "**"
+}
+
+// Badge formats a shields.io badge, as inline images need a substitution this is written as text
+func (ReST) Badge(label string, message string, colour string) string {
+	return "[" + rstEscaper.Replace(label+": "+message) + "]"
+}
+
+// Text formats plain text, which is left as it is
+func (ReST) Text(text string) string {
+	return text
+}
+
+// Escape formats plain text so that it is shown exactly as written
+func (ReST) Escape(text string) string {
+	return rstEscaper.Replace(text)
+}
+
+// Diagram formats a mermaid diagram as the directive drawn by sphinxcontrib-mermaid
+func (ReST) Diagram(source string) string {
+	return ".. mermaid::\n\n   " + strings.Replace(strings.TrimSuffix(source, "\n"), "\n", "\n   ", -1) + "\n\n"
+}
+
+// CodeBlock formats source code as a code-block directive
+func (ReST) CodeBlock(language string, source string) string {
+	return ".. code-block:: " + language + "\n\n   " + strings.Replace(strings.TrimSuffix(source, "\n"), "\n", "\n   ", -1) + "\n\n"
+}
+
+// Comment formats a comment, which ends with a blank line as explicit markup must be followed by one
+func (ReST) Comment(text string) string {
+	return ".. " + text + "\n"
+}
+
+// Extension is the file extension used for reStructuredText documents
+func (ReST) Extension() string {
+	return ".rst"
+}
@@ -2,30 +2,90 @@ package writer
 
 import (
 	"bufio"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-// Writer object is used to hold details of the markdown being written
+// markers which surround the generated documentation in a markdown file
+const (
+	BeginMarker = "<!-- BEGIN_TF_DOCS -->"
+	EndMarker   = "<!-- END_TF_DOCS -->"
+)
+
+// text of the markers, which are written as a comment in the format of the file
+const (
+	beginMarkerText = "BEGIN_TF_DOCS"
+	endMarkerText   = "END_TF_DOCS"
+)
+
+// Writer object is used to hold details of the document being written
 type Writer struct {
 	fileName string
 	buffer   string
+	renderer Renderer
 }
 
-// New get a new writer object
+// New get a new writer object which writes markdown
 func New(file string) *Writer {
-	return &Writer{fileName: file}
+	return NewWithRenderer(file, Markdown{})
+}
+
+// NewWithRenderer gets a new writer object which writes in the format of the renderer
+func NewWithRenderer(file string, renderer Renderer) *Writer {
+	return &Writer{fileName: file, renderer: renderer}
+}
+
+// Renderer returns the renderer used to format the document
+func (writer *Writer) Renderer() Renderer {
+	return writer.renderer
 }
 
-// WriteFile writes markdown to disk
+// FileName returns the name of the file being written
+func (writer *Writer) FileName() string {
+	return writer.fileName
+}
+
+// Write adds text which has already been formatted to the file
+func (writer *Writer) Write(text string) {
+	writer.write(text)
+}
+
+// Render works out the full contents of the file
+// only the region between the markers in an existing file is replaced, anything outside the markers is kept
+func (writer *Writer) Render() (string, error) {
+	existing, err := ioutil.ReadFile(writer.fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	begin := writer.renderer.Comment(beginMarkerText)
+	end := writer.renderer.Comment(endMarkerText)
+	content, err := injectDocs(string(existing), writer.buffer, begin, end)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", writer.fileName, err)
+	}
+	return content, nil
+}
+
+// WriteFile writes the document to disk, creating the folder it is in if needed
 func (writer *Writer) WriteFile() error {
+	content, err := writer.Render()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(writer.fileName), 0755)
+	if err != nil {
+		return err
+	}
 	f, err := os.Create(writer.fileName)
 	if err != nil {
 		return err
 	}
 
 	w := bufio.NewWriter(f)
-	_, werr := w.WriteString(writer.buffer)
+	_, werr := w.WriteString(content)
 	if werr != nil {
 		return werr
 	}
@@ -34,14 +94,114 @@ func (writer *Writer) WriteFile() error {
 	return nil
 }
 
+// injectDocs puts the generated docs between the markers in the existing contents of a file
+// if there are no markers then the file was written by a version without them, so it is replaced by the generated docs surrounded by markers
+func injectDocs(existing string, docs string, beginMarker string, endMarker string) (string, error) {
+	wrapped := beginMarker + "\n" + docs + endMarker + "\n"
+	begins := strings.Count(existing, beginMarker)
+	ends := strings.Count(existing, endMarker)
+	if begins == 0 && ends == 0 {
+		return wrapped, nil
+	}
+	if begins != 1 || ends != 1 {
+		return "", fmt.Errorf("expected one %s and one %s marker, found %d and %d", beginMarker, endMarker, begins, ends)
+	}
+	begin := strings.Index(existing, beginMarker)
+	end := strings.Index(existing, endMarker)
+	if end < begin {
+		return "", fmt.Errorf("%s marker comes before %s marker", endMarker, beginMarker)
+	}
+	after := strings.TrimPrefix(strings.TrimPrefix(existing[end+len(endMarker):], "\r"), "\n")
+	return existing[:begin] + wrapped + after, nil
+}
+
+// linkTitleEscaper escapes the characters which would end the title of a link early
+var linkTitleEscaper = strings.NewReplacer("\\", "\\\\", "[", "\\[", "]", "\\]")
+
+// linkURLEscaper encodes the characters which would end the url of a link early
+var linkURLEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
 // MakeLink makes links to include in markdown files
 func MakeLink(title string, url string) string {
-	return "[" + title + "](" + url + ")"
+	return "[" + linkTitleEscaper.Replace(title) + "](" + linkURLEscaper.Replace(url) + ")"
+}
+
+// Bold returns text in bold
+func Bold(text string) string {
+	return "**" + text + "**"
+}
+
+// markdownEscaper escapes the characters which have a special meaning in markdown
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"#", "\\#",
+	"|", "\\|",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// Escape escapes text so that it is shown as written rather than being treated as markdown
+func Escape(text string) string {
+	return markdownEscaper.Replace(text)
 }
 
 // InlineCode returns code in backticks
+// code containing backticks is wrapped in a longer run of backticks than any it contains, and is padded with
+// spaces if it starts or ends with a backtick, as empty code cannot be shown an empty string is returned for it
+// code spans cannot hold line breaks, so each line of code with more than one line is put in its own span, joined by <br>
 func InlineCode(code string) string {
-	return "`" + code + "`"
+	if code == "" {
+		return ""
+	}
+	if strings.ContainsAny(code, "\r\n") {
+		lines := strings.Split(strings.Trim(strings.Replace(code, "\r\n", "\n", -1), "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = InlineCode(line)
+		}
+		return strings.Join(lines, "<br>")
+	}
+	longest, run := 0, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// escapeCell makes text safe to use in a table cell
+// pipes which are not already escaped are escaped and line breaks are replaced by <br>
+func escapeCell(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range text {
+		if c == '|' && !escaped {
+			b.WriteRune('\\')
+		}
+		escaped = c == '\\' && !escaped
+		b.WriteRune(c)
+	}
+	lines := strings.Split(strings.Trim(strings.Replace(b.String(), "\r\n", "\n", -1), "\n"), "\n")
+	return strings.Join(lines, "<br>")
+}
+
+// MakeBadge makes a shields.io badge image to include in markdown files
+func MakeBadge(label string, message string, colour string) string {
+	return "![" + label + ": " + message + "](" + badgeURL(label, message, colour) + ")"
 }
 
 // GetBuf returns the buffer from memory
@@ -49,69 +209,80 @@ func (writer *Writer) GetBuf() string {
 	return writer.buffer
 }
 
-func (writer *Writer) writeLine(line string) {
-	writer.buffer = writer.buffer + line + "\n"
+func (writer *Writer) write(text string) {
+	writer.buffer = writer.buffer + text
 }
 
 // H1 adds a h1 to the file
 func (writer *Writer) H1(line string) {
-	writer.writeLine("# " + line)
-	writer.writeLine("")
+	writer.write(writer.renderer.Heading(1, line))
 }
 
 // H1Underline adds a h1 with underline to the file
 func (writer *Writer) H1Underline(line string) {
-	writer.writeLine(line)
-	writer.writeLine("======")
-	writer.writeLine("")
+	writer.write(writer.renderer.Heading(1, line))
 }
 
 // H2 adds a h2 to the file
 func (writer *Writer) H2(line string) {
-	writer.writeLine("## " + line)
-	writer.writeLine("")
+	writer.write(writer.renderer.Heading(2, line))
 }
 
 // H2Underline adds a h2 with underline to the file
 func (writer *Writer) H2Underline(line string) {
-	writer.writeLine(line)
-	writer.writeLine("------")
-	writer.writeLine("")
+	writer.write(writer.renderer.Heading(2, line))
 }
 
 // H3 adds a h3 to the file
 func (writer *Writer) H3(line string) {
-	writer.writeLine("### " + line)
-	writer.writeLine("")
+	writer.write(writer.renderer.Heading(3, line))
 }
 
 // P adds a block of text
 func (writer *Writer) P(line string) {
-	writer.writeLine("")
-	writer.writeLine(line)
-	writer.writeLine("")
+	writer.write(writer.renderer.Paragraph(line))
+}
+
+// Quote adds a block quote
+func (writer *Writer) Quote(line string) {
+	writer.write(writer.renderer.Quote(line))
 }
 
-// Bullet creates a bullet point
+// ListStart starts a list of bullet points
+func (writer *Writer) ListStart() {
+	writer.write(writer.renderer.ListStart())
+}
+
+// Bullet creates a bullet point, which should be between ListStart and ListEnd
 func (writer *Writer) Bullet(line string) {
-	writer.writeLine("* " + line)
+	writer.write(writer.renderer.Bullet(line))
+}
+
+// ListEnd finishes a list of bullet points
+func (writer *Writer) ListEnd() {
+	writer.write(writer.renderer.ListEnd())
 }
 
 // Table creates a table
 func (writer *Writer) Table(headers []string, rows [][]string) {
-	var headerLine string
-	headerLine = "|" + strings.Join(headers, " | ") + "|"
-	writer.writeLine(headerLine)
-	hyphens := make([]string, len(headers))
-	var hyphensLine string
-	for i := 0; i < len(headers); i++ {
-		hyphens[i] = "---"
-	}
-	hyphensLine = strings.Join(hyphens, " | ")
-	writer.writeLine(hyphensLine)
+	writer.TableHeader(headers)
 	for _, row := range rows {
-		rowLine := strings.Join(row, " | ")
-		writer.writeLine(rowLine)
+		writer.TableRow(row)
 	}
-	writer.writeLine("")
+	writer.TableEnd()
+}
+
+// TableHeader starts a table with a row of headers
+func (writer *Writer) TableHeader(headers []string) {
+	writer.write(writer.renderer.TableHeader(headers))
+}
+
+// TableRow adds a row to a table
+func (writer *Writer) TableRow(row []string) {
+	writer.write(writer.renderer.TableRow(row))
+}
+
+// TableEnd finishes a table
+func (writer *Writer) TableEnd() {
+	writer.write(writer.renderer.TableEnd())
 }
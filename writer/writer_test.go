@@ -0,0 +1,122 @@
+package writer
+
+import (
+	"testing"
+)
+
+func TestInjectDocsNoMarkers(t *testing.T) {
+	want := "<!-- BEGIN_TF_DOCS -->\ndocs\n<!-- END_TF_DOCS -->\n"
+	got, err := injectDocs("", "docs\n", BeginMarker, EndMarker)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInjectDocsReplacesUnmarkedContent(t *testing.T) {
+	// a README without markers was written by an earlier version, so it is replaced rather than kept
+	existing := "# vpc\n\nold generated docs\n"
+	want := "<!-- BEGIN_TF_DOCS -->\ndocs\n<!-- END_TF_DOCS -->\n"
+	got, err := injectDocs(existing, "docs\n", BeginMarker, EndMarker)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// content added around the markers afterwards is kept
+	edited := "# Usage guide\n\n" + got + "\nHand written caveats\n"
+	again, err := injectDocs(edited, "docs\n", BeginMarker, EndMarker)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if again != edited {
+		t.Errorf("got %q, want %q", again, edited)
+	}
+}
+
+func TestInjectDocsBetweenMarkers(t *testing.T) {
+	existing := "# Usage guide\n\n<!-- BEGIN_TF_DOCS -->\nold docs\n<!-- END_TF_DOCS -->\n\nHand written caveats\n"
+	want := "# Usage guide\n\n<!-- BEGIN_TF_DOCS -->\nnew docs\n<!-- END_TF_DOCS -->\n\nHand written caveats\n"
+	got, err := injectDocs(existing, "new docs\n", BeginMarker, EndMarker)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInjectDocsMalformedMarkers(t *testing.T) {
+	malformed := []string{
+		"<!-- BEGIN_TF_DOCS -->\nno end marker\n",
+		"no begin marker\n<!-- END_TF_DOCS -->\n",
+		"<!-- END_TF_DOCS -->\nwrong order\n<!-- BEGIN_TF_DOCS -->\n",
+		"<!-- BEGIN_TF_DOCS -->\n<!-- BEGIN_TF_DOCS -->\ntwo begin markers\n<!-- END_TF_DOCS -->\n",
+	}
+	for _, existing := range malformed {
+		if _, err := injectDocs(existing, "docs\n", BeginMarker, EndMarker); err == nil {
+			t.Errorf("expected an error for %q", existing)
+		}
+	}
+}
+
+func TestInjectDocsReST(t *testing.T) {
+	begin, end := ReST{}.Comment(beginMarkerText), ReST{}.Comment(endMarkerText)
+	existing := "Usage guide\n===========\n\n.. BEGIN_TF_DOCS\n\nold docs\n\n.. END_TF_DOCS\n\nHand written caveats\n"
+	want := "Usage guide\n===========\n\n.. BEGIN_TF_DOCS\n\nvpc\n---\n\n.. END_TF_DOCS\n\nHand written caveats\n"
+	got, err := injectDocs(existing, "vpc\n---\n\n", begin, end)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// the markers are followed by a blank line, which docutils needs after explicit markup
+	got, err = injectDocs("", "vpc\n---\n\n", begin, end)
+	if err != nil {
+		t.Errorf("Issue %q", err)
+	}
+	if want := ".. BEGIN_TF_DOCS\n\nvpc\n---\n\n.. END_TF_DOCS\n\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInlineCode(t *testing.T) {
+	cases := map[string]string{
+		"":                "",
+		"simple":          "`simple`",
+		"a`b":             "``a`b``",
+		"a``b":            "```a``b```",
+		"`quoted`":        "`` `quoted` ``",
+		"list(any)":       "`list(any)`",
+		"{\n  a = 1\n}\n": "`{`<br>`  a = 1`<br>`}`",
+		"[\r\n\r\n]":      "`[`<br><br>`]`",
+	}
+	for code, want := range cases {
+		if got := InlineCode(code); got != want {
+			t.Errorf("InlineCode(%q) got %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestMakeLink(t *testing.T) {
+	want := `[a \[b\]](../my%20module%28v2%29/README.md)`
+	if got := MakeLink("a [b]", "../my module(v2)/README.md"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTableEscaping(t *testing.T) {
+	want := "|Name | Description|\n--- | ---\n`a\\|b` | first line<br>second \\| line<br>already \\| escaped\n`{`<br>`  a = \"x\\|y\"`<br>`}` | default\n\n"
+	w := New("")
+	w.Table([]string{"Name", "Description"}, [][]string{
+		[]string{InlineCode("a|b"), "first line\r\nsecond | line\nalready \\| escaped\n"},
+		[]string{InlineCode("{\n  a = \"x|y\"\n}"), "default"},
+	})
+	if got := w.GetBuf(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}